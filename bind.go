@@ -0,0 +1,184 @@
+package form
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrorRenderer writes the response for a request that could not be bound by Handler. err is
+// the error returned by Bind.
+type ErrorRenderer func(w http.ResponseWriter, r *http.Request, err error)
+
+// ErrorRenderer sets the function that Handler uses to write the response when a request
+// cannot be decoded. The default is RenderError.
+func (d *Decoder) ErrorRenderer(f ErrorRenderer) {
+	d.errorRenderer = f
+}
+
+// Bind decodes r into a new T with d.DecodeRequest. Validation rules and hooks are run as
+// part of decoding. A nil Decoder uses the options of NewDecoder.
+func Bind[T any](d *Decoder, r *http.Request) (*T, error) {
+	if d == nil {
+		d = NewDecoder()
+	}
+
+	v := new(T)
+	if err := d.DecodeRequest(r, v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Handler returns an http.Handler that binds each request into a new T with Bind and calls h
+// with it. If the request cannot be bound then h is not called and the error is written with
+// the ErrorRenderer of d. A nil Decoder uses the options of NewDecoder.
+func Handler[T any](d *Decoder, h func(w http.ResponseWriter, r *http.Request, v *T)) http.Handler {
+	if d == nil {
+		d = NewDecoder()
+	}
+
+	render := d.errorRenderer
+	if render == nil {
+		render = RenderError
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v, err := Bind[T](d, r)
+		if err != nil {
+			render(w, r, err)
+			return
+		}
+
+		h(w, r, v)
+	})
+}
+
+// ErrorStatus returns the HTTP status code for an error returned by Bind or DecodeRequest.
+// Bodies that are too large or are not forms return 413 and 415. Errors caused by the type
+// being decoded into rather than the request return 500. Any other error is a bad request.
+func ErrorStatus(err error) int {
+	var (
+		sizeErr      *BodyTooLargeError
+		mediaErr     *UnsupportedContentTypeError
+		usageErr     *UsageTypeError
+		typeErr      *FieldTypeError
+		duplicateErr *DuplicateFieldError
+		sourceErr    *SourceError
+		styleErr     *SliceStyleError
+		optionErr    *TagOptionError
+	)
+
+	switch {
+	case errors.As(err, &sizeErr):
+		return http.StatusRequestEntityTooLarge
+
+	case errors.As(err, &mediaErr):
+		return http.StatusUnsupportedMediaType
+
+	case errors.As(err, &usageErr), errors.As(err, &typeErr), errors.As(err, &duplicateErr), errors.As(err, &sourceErr),
+		errors.As(err, &styleErr), errors.As(err, &optionErr), errors.Is(err, errUnknownRule),
+		errors.Is(err, errInvalidRule), errors.Is(err, errUnknownFileLimit), errors.Is(err, errInvalidFileLimit):
+		return http.StatusInternalServerError
+	}
+
+	return http.StatusBadRequest
+}
+
+// ErrorDetail describes a single problem with a request in the responses written by
+// RenderError.
+type ErrorDetail struct {
+	// Field is the full path to the field, such as Billing.Address.Zip or Items[0].SKU.
+	Field string `json:"field,omitempty"`
+	// Key is the form key that the values were submitted with.
+	Key     string `json:"key,omitempty"`
+	Message string `json:"message"`
+}
+
+// ErrorDetails returns a detail for each error held by err, which can be DecodeErrors. It can
+// be used to write a custom ErrorRenderer.
+func ErrorDetails(err error) []ErrorDetail {
+	errs, ok := err.(DecodeErrors)
+	if !ok {
+		errs = DecodeErrors{err}
+	}
+
+	details := make([]ErrorDetail, 0, len(errs))
+	for _, err := range errs {
+		details = append(details, errorDetail(err))
+	}
+
+	return details
+}
+
+func errorDetail(err error) ErrorDetail {
+	switch e := err.(type) {
+	case *FieldParseError:
+		return ErrorDetail{Field: e.path(), Key: e.Key, Message: errorMessage(e.Err)}
+
+	case *RequiredFieldError:
+		path := e.Path
+		if path == "" {
+			path = e.Field
+		}
+
+		return ErrorDetail{Field: path, Message: "required"}
+
+	case *UnexpectedFieldError:
+		return ErrorDetail{Field: e.Path, Key: e.Key, Message: errorMessage(e)}
+
+	case *HookError:
+		return ErrorDetail{Field: e.Path, Message: errorMessage(e.Err)}
+	}
+
+	return ErrorDetail{Message: errorMessage(err)}
+}
+
+// errorMessage returns the message of err without the prefix added by this package.
+func errorMessage(err error) string {
+	return strings.TrimPrefix(err.Error(), buildErrorMessage("Parse", ""))
+}
+
+// RenderError is the default ErrorRenderer. It writes the status from ErrorStatus and the
+// details from ErrorDetails, as JSON if the request accepts application/json or as plain text
+// with one detail per line otherwise. The details of errors with a 500 status are not
+// written as they are not caused by the request.
+//
+// The JSON has the form:
+//
+//	{"errors": [{"field": "Age", "key": "age", "message": "min: must be at least 18"}]}
+func RenderError(w http.ResponseWriter, r *http.Request, err error) {
+	status := ErrorStatus(err)
+
+	var details []ErrorDetail
+	if status == http.StatusInternalServerError {
+		details = []ErrorDetail{{Message: http.StatusText(status)}}
+	} else {
+		details = ErrorDetails(err)
+	}
+
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(struct {
+			Errors []ErrorDetail `json:"errors"`
+		}{details})
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	for _, detail := range details {
+		line := detail.Message
+		if detail.Field != "" {
+			line = detail.Field + ": " + line
+		}
+
+		w.Write([]byte(line + "\n"))
+	}
+}
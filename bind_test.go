@@ -0,0 +1,226 @@
+package form
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type bindForm struct {
+	Name string `form:"name,required"`
+	Age  int    `form:"age" validate:"min=18"`
+}
+
+func TestBind(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?name=n&age=20", nil)
+	v, err := Bind[bindForm](nil, r)
+	if err != nil {
+		t.Fatalf("Bind: %q", err)
+	}
+
+	if v.Name != "n" || v.Age != 20 {
+		t.Errorf("Unexpected value. Expected {Name:n Age:20} found %+v", *v)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?age=2", nil)
+	if _, err := Bind[bindForm](nil, r); err == nil {
+		t.Errorf("Expected an error for an invalid request")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var called *bindForm
+	h := Handler(nil, func(w http.ResponseWriter, r *http.Request, v *bindForm) {
+		called = v
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?name=n&age=20", nil))
+	if w.Code != http.StatusNoContent || called == nil || called.Name != "n" {
+		t.Errorf("Unexpected response. Expected 204 with name n found %d with %+v", w.Code, called)
+	}
+
+	called = nil
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?age=2", nil))
+	if called != nil {
+		t.Errorf("Handler called for an invalid request")
+	}
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Unexpected status. Expected 400 found %d", w.Code)
+	}
+
+	want := "Age: min: must be at least 18\nName: required\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("Unexpected body. Expected %q found %q", want, got)
+	}
+
+	if got := w.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Unexpected content type. Expected text/plain found %q", got)
+	}
+}
+
+func TestHandlerJSON(t *testing.T) {
+	h := Handler(nil, func(w http.ResponseWriter, r *http.Request, v *bindForm) {})
+
+	r := httptest.NewRequest(http.MethodGet, "/?age=x&extra=1", nil)
+	r.Header.Set("Accept", "application/json")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Unexpected status. Expected 400 found %d", w.Code)
+	}
+
+	var body struct {
+		Errors []ErrorDetail `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Unmarshal %q: %q", w.Body.String(), err)
+	}
+
+	if len(body.Errors) != 2 {
+		t.Fatalf("Unexpected number of errors. Expected 2 found %d", len(body.Errors))
+	}
+
+	if got := body.Errors[0]; got.Field != "Age" || got.Key != "age" || got.Message != "unexpected value x" {
+		t.Errorf("Unexpected error for age: %+v", got)
+	}
+
+	if got := body.Errors[1]; got.Field != "Name" || got.Message != "required" {
+		t.Errorf("Unexpected error for name: %+v", got)
+	}
+}
+
+func TestHandlerStatus(t *testing.T) {
+	d := NewDecoder()
+	d.MaxBodySize(4)
+	h := Handler(d, func(w http.ResponseWriter, r *http.Request, v *bindForm) {})
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"too large", "application/x-www-form-urlencoded", "name=name", http.StatusRequestEntityTooLarge},
+		{"media type", "application/json", "{}", http.StatusUnsupportedMediaType},
+		{"malformed", "application/x-www-form-urlencoded", "%zz", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+		r.Header.Set("Content-Type", test.contentType)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != test.want {
+			t.Errorf("Unexpected status for %s. Expected %d found %d", test.name, test.want, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	Handler(nil, func(w http.ResponseWriter, r *http.Request, v *int) {}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "int") {
+		t.Errorf("Unexpected response for an invalid type. Expected 500 without details found %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestHandlerRenderer(t *testing.T) {
+	var rendered error
+	d := NewDecoder()
+	d.ErrorRenderer(func(w http.ResponseWriter, r *http.Request, err error) {
+		rendered = err
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	w := httptest.NewRecorder()
+	Handler(d, func(w http.ResponseWriter, r *http.Request, v *bindForm) {}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var requiredErr *RequiredFieldError
+	if !errors.As(rendered, &requiredErr) {
+		t.Errorf("Expected RequiredFieldError found %v", rendered)
+	}
+
+	if w.Code != http.StatusTeapot {
+		t.Errorf("Unexpected status. Expected 418 found %d", w.Code)
+	}
+}
+
+func TestErrorStatusTags(t *testing.T) {
+	var dst struct {
+		Age int `form:"age" validate:"mni=3"`
+	}
+
+	err := NewDecoder().Decode(map[string][]string{"age": {"4"}}, &dst)
+	if status := ErrorStatus(err); status != http.StatusInternalServerError {
+		t.Errorf("Unexpected status for an unknown rule. Expected 500 found %d: %q", status, err)
+	}
+
+	for _, test := range []struct {
+		name string
+		dst  interface{}
+	}{
+		{"a min that is not a number", &struct {
+			Age int `form:"age" validate:"min=abc"`
+		}{}},
+		{"an invalid pattern", &struct {
+			Age string `form:"age" validate:"pattern=[a-"`
+		}{}},
+		{"a min on a string", &struct {
+			Age string `form:"age" validate:"min=3"`
+		}{}},
+		{"a length on a number", &struct {
+			Age int `form:"age" validate:"maxlen=3"`
+		}{}},
+		{"an email on a number", &struct {
+			Age int `form:"age" validate:"email"`
+		}{}},
+	} {
+		err := NewDecoder().Decode(map[string][]string{"age": {"4"}}, test.dst)
+		if status := ErrorStatus(err); status != http.StatusInternalServerError {
+			t.Errorf("Unexpected status for %s. Expected 500 found %d: %q", test.name, status, err)
+		}
+	}
+
+	// A value that fails a valid rule is still a bad request.
+	var short struct {
+		Age int `form:"age" validate:"min=5"`
+	}
+
+	err = NewDecoder().Decode(map[string][]string{"age": {"4"}}, &short)
+	if status := ErrorStatus(err); status != http.StatusBadRequest {
+		t.Errorf("Unexpected status for a failed rule. Expected 400 found %d: %q", status, err)
+	}
+
+	form := readMultipart(t, testPart{key: "Avatar", filename: "a.png", content: pngHeader})
+
+	var unknown struct {
+		Avatar File `file:"maxsz=16"`
+	}
+
+	err = NewDecoder().DecodeMultipart(form, &unknown)
+	if status := ErrorStatus(err); status != http.StatusInternalServerError {
+		t.Errorf("Unexpected status for an unknown file limit. Expected 500 found %d: %q", status, err)
+	}
+
+	var invalid struct {
+		Avatar File `file:"maxsize=big"`
+	}
+
+	err = NewDecoder().DecodeMultipart(form, &invalid)
+	if status := ErrorStatus(err); status != http.StatusInternalServerError {
+		t.Errorf("Unexpected status for an invalid file limit. Expected 500 found %d: %q", status, err)
+	}
+
+	// File tags are checked even if no file is uploaded.
+	err = NewDecoder().Decode(map[string][]string{}, &invalid)
+	if status := ErrorStatus(err); status != http.StatusInternalServerError {
+		t.Errorf("Unexpected status for an invalid file limit without a file. Expected 500 found %d: %q", status, err)
+	}
+}
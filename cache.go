@@ -0,0 +1,162 @@
+package form
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// typeField is an exported field of a struct type with its options. The fields of anonymous
+// structs are flattened into the struct that embeds them.
+type typeField struct {
+	// index is the index sequence for reflect.Value.FieldByIndex.
+	index  []int
+	goName string
+	typ    reflect.Type
+	opts   *fieldOptions
+}
+
+// fieldCache holds the []typeField for each struct type. The fields do not depend on any
+// options so they are shared by the Decoder and Encoder.
+var fieldCache sync.Map
+
+func cachedTypeFields(t reflect.Type) []typeField {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]typeField)
+	}
+
+	fields, _ := fieldCache.LoadOrStore(t, typeFields(t, nil))
+	return fields.([]typeField)
+}
+
+func typeFields(t reflect.Type, index []int) []typeField {
+	var fields []typeField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		isUnexported := field.PkgPath != ""
+		if isUnexported {
+			continue
+		}
+
+		opts := readFieldOptions(field)
+		if opts.skip {
+			continue
+		}
+
+		fieldIndex := append(index[:len(index):len(index)], i)
+
+		// Add anonymous structs values at the same level as the current
+		if field.Type.Kind() == reflect.Struct && field.Anonymous {
+			fields = append(fields, typeFields(field.Type, fieldIndex)...)
+			continue
+		}
+
+		fields = append(fields, typeField{index: fieldIndex, goName: field.Name, typ: field.Type, opts: opts})
+	}
+
+	return fields
+}
+
+// formEntry is a struct field that can be decoded.
+type formEntry struct {
+	index []int
+	// name is the form name of the field.
+	name string
+	// goName is the name of the field in the struct, which may differ from the form name.
+	goName string
+	typ    reflect.Type
+	opts   *fieldOptions
+	// sub is the plan for the fields of a struct field when Recurse is set.
+	sub *structPlan
+}
+
+// structPlan is the cached information needed to decode a struct type.
+type structPlan struct {
+	// entries are keyed by form name, which is lower case if the decoder is case insensitive.
+	entries map[string]*formEntry
+	// keys are the keys of entries in sorted order.
+	keys []string
+	// names are the form names of the fields, used to suggest fields for unknown keys.
+	names []string
+}
+
+// emptyPlan has no fields. It is used for values that cannot hold sub keys.
+var emptyPlan = &structPlan{}
+
+type planKey struct {
+	t       reflect.Type
+	toLower bool
+	recurse bool
+}
+
+// planCache holds the *structPlan for each planKey.
+var planCache sync.Map
+
+// cachedStructPlan returns the plan for decoding the struct type t. Plans are built once and
+// are safe to use concurrently.
+func cachedStructPlan(t reflect.Type, toLower, recurse bool) (*structPlan, error) {
+	key := planKey{t: t, toLower: toLower, recurse: recurse}
+	if plan, ok := planCache.Load(key); ok {
+		return plan.(*structPlan), nil
+	}
+
+	plan, err := newStructPlan(t, toLower, recurse)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, _ := planCache.LoadOrStore(key, plan)
+	return cached.(*structPlan), nil
+}
+
+func newStructPlan(t reflect.Type, toLower, recurse bool) (*structPlan, error) {
+	fields := cachedTypeFields(t)
+	plan := &structPlan{
+		entries: make(map[string]*formEntry, len(fields)),
+		keys:    make([]string, 0, len(fields)),
+		names:   make([]string, 0, len(fields)),
+	}
+
+	for _, field := range fields {
+		if field.opts.err != nil {
+			return nil, field.opts.err
+		}
+
+		name := field.opts.name
+		keyName := name
+		if toLower {
+			keyName = strings.ToLower(name)
+		}
+
+		if _, ok := plan.entries[keyName]; ok {
+			return nil, &DuplicateFieldError{Field: name}
+		}
+
+		entry := &formEntry{
+			index:  field.index,
+			name:   name,
+			goName: field.goName,
+			typ:    field.typ,
+			opts:   field.opts,
+		}
+
+		if recurse && field.typ.Kind() == reflect.Struct && field.typ != fileType {
+			sub, err := cachedStructPlan(field.typ, toLower, recurse)
+			if err != nil {
+				return nil, err
+			}
+
+			entry.sub = sub
+		}
+
+		plan.entries[keyName] = entry
+		plan.keys = append(plan.keys, keyName)
+		plan.names = append(plan.names, name)
+	}
+
+	sort.Strings(plan.keys)
+
+	return plan, nil
+}
@@ -0,0 +1,988 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mattpgray/form"
+)
+
+// generatedHeader starts every generated file. Files that start with it are not read when
+// loading the package so that stale generated code does not stop it from type checking.
+const generatedHeader = "// Code generated by formgen. DO NOT EDIT."
+
+// valueKind is how a single value is decoded or encoded.
+type valueKind int
+
+const (
+	kindUnsupported valueKind = iota
+	kindFieldParser
+	kindFieldEncoder
+	kindText
+	kindDuration
+	kindBool
+	kindInt
+	kindUint
+	kindFloat
+	kindString
+)
+
+// shape is how a field holds its values.
+type shape int
+
+const (
+	shapeScalar shape = iota
+	shapePointer
+	shapeSlice
+	shapeStruct
+)
+
+type genField struct {
+	// id numbers the variables that hold the form values of the field.
+	id int
+	// name is the form name of the field.
+	name string
+	// key is the name matched against the form keys, which is lower case if the keys are
+	// case insensitive.
+	key string
+	// path is the Go path of the field used in errors, such as Billing.Zip.
+	path string
+	// expr is the Go expression for the field, such as x.Billing.Zip.
+	expr string
+	// parts are the keys from the top level struct to the field.
+	parts []string
+	typ   types.Type
+
+	omitEmpty  bool
+	required   bool
+	hasDefault bool
+	defaultVal string
+
+	shape shape
+	// elem is the type of the values of scalar, pointer and slice fields.
+	elem     types.Type
+	dec, enc valueKind
+	// holdsSubKeys is set for fields that the Decoder would decode sub keys into, which the
+	// generated code leaves to the reflection based Decoder.
+	holdsSubKeys bool
+
+	sub *genStruct
+}
+
+type genStruct struct {
+	path   string
+	expr   string
+	fields []*genField
+	// byKey are the fields in key order, which is the order the Decoder reports errors in.
+	byKey []*genField
+
+	before, after, validate bool
+}
+
+type generator struct {
+	pkg  *types.Package
+	opts form.GeneratedOptions
+	buf  bytes.Buffer
+	// imports are the import paths used by the generated code.
+	imports map[string]bool
+	nextID  int
+}
+
+// generate returns the formatted source of the methods for the types in the package in dir.
+func generate(dir string, typeNames []string, opts form.GeneratedOptions) ([]byte, error) {
+	pkg, err := loadPackage(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return generatePackage(pkg, typeNames, opts)
+}
+
+// generatePackage returns the formatted source of the methods for the types in pkg.
+func generatePackage(pkg *types.Package, typeNames []string, opts form.GeneratedOptions) ([]byte, error) {
+	g := &generator{
+		pkg:     pkg,
+		opts:    opts,
+		imports: map[string]bool{"net/url": true, "github.com/mattpgray/form": true},
+	}
+
+	for _, name := range typeNames {
+		obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("type %s not found in package %s", name, pkg.Name())
+		}
+
+		s, err := g.buildStruct(obj.Type(), "x", "", nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		g.writeType(name, s)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%s\n\npackage %s\n\nimport (\n", generatedHeader, pkg.Name())
+
+	// Standard library imports are grouped before the others.
+	var std, other []string
+	for path := range g.imports {
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+
+	sort.Strings(std)
+	sort.Strings(other)
+
+	for _, path := range std {
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+
+	out.WriteString("\n")
+	for _, path := range other {
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+
+	fmt.Fprintf(&out, ")\n")
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+
+	return src, nil
+}
+
+// loadPackage parses and type checks the package in dir, skipping generated files.
+func loadPackage(dir string) (*types.Package, error) {
+	buildPkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range buildPkg.GoFiles {
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(file.Comments) > 0 && file.Comments[0].Pos() < file.Package &&
+			strings.HasPrefix(file.Comments[0].List[0].Text, generatedHeader) {
+			continue
+		}
+
+		files = append(files, file)
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	return conf.Check(buildPkg.ImportPath, fset, files, nil)
+}
+
+// buildStruct reads the fields of the struct type t. expr and path are the Go expression
+// and path of the struct and parts are its form keys.
+func (g *generator) buildStruct(t types.Type, expr, path string, parts []string) (*genStruct, error) {
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct", t)
+	}
+
+	s := &genStruct{
+		path:     path,
+		expr:     expr,
+		before:   hasMethod(t, "BeforeDecode", nil, []string{"error"}),
+		after:    hasMethod(t, "AfterDecode", nil, []string{"error"}),
+		validate: hasMethod(t, "Validate", nil, []string{"error"}),
+	}
+
+	if err := g.addFields(s, st, expr, path, parts); err != nil {
+		return nil, err
+	}
+
+	s.byKey = append([]*genField(nil), s.fields...)
+	sort.Slice(s.byKey, func(i, j int) bool {
+		return s.byKey[i].key < s.byKey[j].key
+	})
+
+	for i := 1; i < len(s.byKey); i++ {
+		if s.byKey[i].key == s.byKey[i-1].key {
+			return nil, fmt.Errorf("duplicate field %s", s.byKey[i].name)
+		}
+	}
+
+	return s, nil
+}
+
+// addFields adds the fields of st to s. The fields of anonymous structs are added at the
+// same level in the same way as the Decoder.
+func (g *generator) addFields(s *genStruct, st *types.Struct, expr, path string, parts []string) error {
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		if !v.Exported() {
+			continue
+		}
+
+		tag := reflect.StructTag(st.Tag(i))
+		formTag := tag.Get("form")
+		if formTag == "-" {
+			continue
+		}
+
+		fieldExpr := expr + "." + v.Name()
+
+		if _, ok := v.Type().(*types.Named); v.Embedded() && ok {
+			if embedded, ok := v.Type().Underlying().(*types.Struct); ok {
+				if err := g.addFields(s, embedded, fieldExpr, path, parts); err != nil {
+					return err
+				}
+
+				continue
+			}
+		}
+
+		if _, ok := tag.Lookup("validate"); ok {
+			return fmt.Errorf("field %s: validate tags are not supported", v.Name())
+		}
+
+		fieldPath := v.Name()
+		if path != "" {
+			fieldPath = path + "." + v.Name()
+		}
+
+		f := &genField{
+			id:   g.nextID,
+			name: v.Name(),
+			path: fieldPath,
+			expr: fieldExpr,
+			typ:  v.Type(),
+		}
+		g.nextID++
+
+		options := strings.Split(formTag, ",")
+		if options[0] != "" {
+			f.name = options[0]
+		}
+
+		for _, option := range options[1:] {
+			option, value, _ := strings.Cut(option, "=")
+			switch option {
+			case "omitempty":
+				f.omitEmpty = true
+
+			case "required":
+				f.required = true
+
+			case "default":
+				f.hasDefault = true
+				f.defaultVal = value
+
+			case "source":
+				// DecodeRequest reads sources into the form values before decoding.
+
+			case "slice":
+				return fmt.Errorf("field %s: slice styles are not supported", v.Name())
+
+			default:
+				return fmt.Errorf("field %s: unknown option %q", v.Name(), option)
+			}
+		}
+
+		f.key = f.name
+		if !g.opts.StrictCase {
+			f.key = strings.ToLower(f.name)
+		}
+
+		f.parts = append(parts[:len(parts):len(parts)], f.name)
+
+		if err := g.classify(f); err != nil {
+			return fmt.Errorf("field %s: %w", v.Name(), err)
+		}
+
+		s.fields = append(s.fields, f)
+	}
+
+	return nil
+}
+
+// classify works out how the field is decoded and encoded.
+func (g *generator) classify(f *genField) error {
+	if isNamed(f.typ, "time", "Time") {
+		// The layout and location of times are options of the Decoder and Encoder.
+		return fmt.Errorf("unsupported type %s", f.typ)
+	}
+
+	if isFile(f.typ) {
+		// Files are only in multipart forms, which DecodeForm does not see.
+		return fmt.Errorf("unsupported file type %s", f.typ)
+	}
+
+	if dec, enc := valueKinds(f.typ); dec != kindUnsupported || enc != kindUnsupported {
+		if dec == kindUnsupported || enc == kindUnsupported {
+			return fmt.Errorf("type %s can only be decoded or encoded, not both", f.typ)
+		}
+
+		f.shape, f.elem, f.dec, f.enc = shapeScalar, f.typ, dec, enc
+		f.holdsSubKeys = canHoldSubKeys(f.typ)
+		return nil
+	}
+
+	switch t := f.typ.Underlying().(type) {
+	case *types.Pointer:
+		f.shape, f.elem = shapePointer, t.Elem()
+		f.holdsSubKeys = canHoldSubKeys(t.Elem())
+
+	case *types.Slice:
+		f.shape, f.elem = shapeSlice, t.Elem()
+		f.holdsSubKeys = true
+
+	case *types.Struct:
+		if g.opts.Recurse == "" {
+			return fmt.Errorf("struct type %s needs -recurse", f.typ)
+		}
+
+		if f.hasDefault {
+			return fmt.Errorf("struct fields cannot have a default")
+		}
+
+		sub, err := g.buildStruct(f.typ, f.expr, f.path, f.parts)
+		if err != nil {
+			return err
+		}
+
+		f.shape, f.sub = shapeStruct, sub
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported type %s", f.typ)
+	}
+
+	f.dec, f.enc = valueKinds(f.elem)
+	if f.dec == kindUnsupported || f.enc == kindUnsupported {
+		return fmt.Errorf("unsupported type %s", f.typ)
+	}
+
+	return nil
+}
+
+// valueKinds returns how a single value of type t is decoded and encoded, in the same order
+// as the Decoder and Encoder check for them.
+func valueKinds(t types.Type) (dec, enc valueKind) {
+	if isNamed(t, "time", "Time") {
+		return kindUnsupported, kindUnsupported
+	}
+
+	switch {
+	case hasMethod(t, "ParseField", []string{"string", "[]string"}, []string{"error"}):
+		dec = kindFieldParser
+	case isNamed(t, "time", "Duration"):
+		dec = kindDuration
+	case hasMethod(t, "UnmarshalText", []string{"[]byte"}, []string{"error"}):
+		dec = kindText
+	default:
+		dec = basicKind(t)
+	}
+
+	switch {
+	case hasMethod(t, "EncodeField", nil, []string{"[]string", "error"}):
+		enc = kindFieldEncoder
+	case isNamed(t, "time", "Duration"):
+		enc = kindDuration
+	case hasMethod(t, "MarshalText", nil, []string{"[]byte", "error"}):
+		enc = kindText
+	default:
+		enc = basicKind(t)
+	}
+
+	return dec, enc
+}
+
+func basicKind(t types.Type) valueKind {
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return kindUnsupported
+	}
+
+	switch info := basic.Info(); {
+	case info&types.IsBoolean != 0:
+		return kindBool
+	case info&types.IsInteger != 0 && info&types.IsUnsigned != 0:
+		if basic.Kind() == types.Uintptr {
+			return kindUnsupported
+		}
+
+		return kindUint
+	case info&types.IsInteger != 0:
+		return kindInt
+	case info&types.IsFloat != 0:
+		return kindFloat
+	case info&types.IsString != 0:
+		return kindString
+	}
+
+	return kindUnsupported
+}
+
+// canHoldSubKeys reports whether the Decoder would decode sub keys into a value of type t.
+func canHoldSubKeys(t types.Type) bool {
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		t = p.Elem()
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Struct, *types.Map, *types.Slice:
+		return true
+	case *types.Interface:
+		return u.Empty()
+	}
+
+	return false
+}
+
+// isFile reports whether t is one of the file field types or a pointer to or slice of them.
+func isFile(t types.Type) bool {
+	for {
+		switch u := t.(type) {
+		case *types.Pointer:
+			t = u.Elem()
+			continue
+
+		case *types.Slice:
+			t = u.Elem()
+			continue
+		}
+
+		return isNamed(t, "github.com/mattpgray/form", "File") || isNamed(t, "mime/multipart", "FileHeader")
+	}
+}
+
+func isNamed(t types.Type, pkg, name string) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == pkg && named.Obj().Name() == name
+}
+
+// hasMethod reports whether *t has the method with the parameter and result types.
+func hasMethod(t types.Type, name string, params, results []string) bool {
+	sel := types.NewMethodSet(types.NewPointer(t)).Lookup(nil, name)
+	if sel == nil {
+		return false
+	}
+
+	sig, ok := sel.Type().(*types.Signature)
+	if !ok {
+		return false
+	}
+
+	return tupleIs(sig.Params(), params) && tupleIs(sig.Results(), results) && !sig.Variadic()
+}
+
+func tupleIs(tuple *types.Tuple, want []string) bool {
+	if tuple.Len() != len(want) {
+		return false
+	}
+
+	for i := range want {
+		if tuple.At(i).Type().String() != want[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// typeString returns the type as it is written in the generated file.
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, func(pkg *types.Package) string {
+		if pkg == g.pkg {
+			return ""
+		}
+
+		g.imports[pkg.Path()] = true
+		return pkg.Name()
+	})
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+func (g *generator) writeType(name string, s *genStruct) {
+	g.printf("")
+	g.printf("// FormOptions returns the options that the form methods of %s were generated for.", name)
+	g.printf("func (%s) FormOptions() form.GeneratedOptions {", name)
+	g.printf("return form.GeneratedOptions{StrictCase: %t, Recurse: %q}", g.opts.StrictCase, g.opts.Recurse)
+	g.printf("}")
+
+	g.writeDecode(name, s)
+	g.writeEncode(name, s)
+}
+
+// allFields returns the fields of s and every nested struct.
+func (s *genStruct) allFields() []*genField {
+	var fields []*genField
+	for _, f := range s.fields {
+		fields = append(fields, f)
+		if f.sub != nil {
+			fields = append(fields, f.sub.allFields()...)
+		}
+	}
+
+	return fields
+}
+
+func (g *generator) writeDecode(name string, s *genStruct) {
+	g.printf("")
+	g.printf("// DecodeForm decodes vals into x in the same way as a form.Decoder with the options from")
+	g.printf("// FormOptions.")
+	g.printf("func (x *%s) DecodeForm(vals url.Values) error {", name)
+
+	fields := s.allFields()
+	if len(fields) > 0 {
+		g.printf("var (")
+		for _, f := range fields {
+			g.printf("vals%d []string", f.id)
+			g.printf("key%d string", f.id)
+			g.printf("set%d bool", f.id)
+			if g.needsSub(f) {
+				g.printf("sub%d bool", f.id)
+			}
+		}
+		g.printf(")")
+
+		g.printf("for key, kv := range vals {")
+		if g.opts.StrictCase {
+			g.printf("k := key")
+		} else {
+			g.imports["strings"] = true
+			g.printf("k := strings.ToLower(key)")
+		}
+
+		if g.opts.Recurse != "" {
+			g.printf("parts := form.%s(k)", decodeFunc(g.opts.Recurse))
+		}
+
+		g.writeScan(s, 0)
+		g.printf("}")
+
+		// The Decoder decodes sub keys into slices by index, which is left to it.
+		for _, f := range fields {
+			if g.opts.Recurse != "" && f.holdsSubKeys {
+				g.printf("if sub%d {", f.id)
+				g.printf("return form.DecodeWithReflection(vals, x, x.FormOptions())")
+				g.printf("}")
+			}
+		}
+	}
+
+	g.writeLevel(s)
+	g.printf("}")
+}
+
+// writeScan writes the switch that matches the form keys to the fields of s.
+func (g *generator) writeScan(s *genStruct, depth int) {
+	if g.opts.Recurse == "" {
+		g.printf("switch k {")
+	} else {
+		g.printf("switch parts[%d] {", depth)
+	}
+
+	for _, f := range s.byKey {
+		g.printf("case %q:", f.key)
+		if g.opts.Recurse != "" {
+			g.printf("if len(parts) > %d {", depth+1)
+			if g.needsSub(f) {
+				g.printf("sub%d = true", f.id)
+			}
+			if f.sub != nil {
+				g.writeScan(f.sub, depth+1)
+			}
+			g.printf("break")
+			g.printf("}")
+		}
+
+		g.printf("if !set%d {", f.id)
+		g.printf("key%d = key", f.id)
+		g.printf("}")
+		g.printf("set%d = true", f.id)
+		g.printf("vals%d = append(vals%d, kv...)", f.id, f.id)
+	}
+
+	g.printf("}")
+}
+
+// needsSub reports whether the generated code needs to know if a field has sub keys.
+func (g *generator) needsSub(f *genField) bool {
+	return g.opts.Recurse != "" && (f.sub != nil || f.holdsSubKeys || needsMissing(f))
+}
+
+// needsMissing reports whether a field has an option that applies when it is not in the form
+// values.
+func needsMissing(f *genField) bool {
+	return f.hasDefault || f.required
+}
+
+// writeLevel writes the statements that decode the struct s and call its hooks. They return
+// the errors for the struct.
+func (g *generator) writeLevel(s *genStruct) {
+	if s.before {
+		g.printf("if err := %s.BeforeDecode(); err != nil {", s.expr)
+		g.printf("return &form.HookError{Path: %q, Hook: \"BeforeDecode\", Err: err}", s.path)
+		g.printf("}")
+	}
+
+	g.printf("var errs form.DecodeErrors")
+
+	// Nested structs are decoded by a function so that they are decoded, and their hooks
+	// called, whether or not they are in the form values.
+	for _, f := range s.byKey {
+		if f.sub != nil {
+			g.printf("decode%d := func() error {", f.id)
+			g.writeLevel(f.sub)
+			g.printf("}")
+		}
+	}
+
+	for _, f := range s.byKey {
+		g.writePresent(f)
+	}
+
+	for _, f := range s.byKey {
+		g.writeMissing(f)
+	}
+
+	g.printf("if len(errs) > 0 {")
+	g.printf("return errs")
+	g.printf("}")
+
+	if s.after {
+		g.printf("if err := %s.AfterDecode(); err != nil {", s.expr)
+		g.printf("return &form.HookError{Path: %q, Hook: \"AfterDecode\", Err: err}", s.path)
+		g.printf("}")
+	}
+
+	if s.validate {
+		g.printf("if err := %s.Validate(); err != nil {", s.expr)
+		g.printf("return &form.HookError{Path: %q, Hook: \"Validate\", Err: err}", s.path)
+		g.printf("}")
+	}
+
+	g.printf("return nil")
+}
+
+// present returns the condition for a field being in the form values.
+func (g *generator) present(f *genField) string {
+	if g.opts.Recurse == "" {
+		return fmt.Sprintf("set%d", f.id)
+	}
+
+	return fmt.Sprintf("(set%d || sub%d)", f.id, f.id)
+}
+
+// writePresent writes the statements that decode a field that is in the form values.
+func (g *generator) writePresent(f *genField) {
+	if f.shape == shapeStruct {
+		g.imports["reflect"] = true
+		g.printf("if set%d {", f.id)
+		g.printf("errs = errs.Add(&form.FieldTypeError{Field: %q, Path: %q, Key: key%d, Vals: vals%d, Type: reflect.TypeOf(%s)})", f.name, f.path, f.id, f.id, f.expr)
+		g.printf("}")
+		g.printf("if sub%d {", f.id)
+		g.printf("if err := decode%d(); err != nil {", f.id)
+		g.printf("errs = errs.Add(err)")
+		g.printf("}")
+		g.printf("}")
+		return
+	}
+
+	g.printf("if set%d {", f.id)
+	g.writeDecodeValue(f, fmt.Sprintf("vals%d", f.id))
+	g.printf("if err != nil {")
+	g.printf("errs = errs.Add(&form.FieldParseError{Field: %q, Path: %q, Key: key%d, Vals: vals%d, Err: err})", f.name, f.path, f.id, f.id)
+	g.printf("}")
+	g.printf("}")
+}
+
+// writeMissing writes the statements that apply the default and required options to a field
+// that is not in the form values, or decode it if it is a nested struct without them.
+func (g *generator) writeMissing(f *genField) {
+	if !needsMissing(f) && f.sub == nil {
+		return
+	}
+
+	g.printf("if !%s {", g.present(f))
+	switch {
+	case f.hasDefault:
+		g.printf("defaultVals := []string{%q}", f.defaultVal)
+		g.writeDecodeValue(f, "defaultVals")
+		g.printf("if err != nil {")
+		g.printf("errs = errs.Add(&form.FieldParseError{Field: %q, Path: %q, Vals: defaultVals, Err: err})", f.name, f.path)
+		g.printf("}")
+
+	case f.required:
+		g.printf("errs = errs.Add(&form.RequiredFieldError{Field: %q, Path: %q})", f.name, f.path)
+
+	default:
+		g.printf("if err := decode%d(); err != nil {", f.id)
+		g.printf("errs = errs.Add(err)")
+		g.printf("}")
+	}
+	g.printf("}")
+}
+
+// writeDecodeValue writes the statements that decode vals into the field and set err.
+func (g *generator) writeDecodeValue(f *genField, vals string) {
+	switch f.shape {
+	case shapeScalar:
+		g.writeDecodeScalar(f.dec, f.elem, f.expr, vals, f.key, "err")
+
+	case shapePointer:
+		g.printf("if %s == nil {", f.expr)
+		g.printf("%s = new(%s)", f.expr, g.typeString(f.elem))
+		g.printf("}")
+		g.writeDecodeScalar(f.dec, f.elem, "*"+f.expr, vals, f.key, "err")
+
+	case shapeSlice:
+		g.printf("var err error")
+		g.printf("set := make(%s, len(%s))", g.typeString(f.typ), vals)
+		g.printf("for i := range %s {", vals)
+		g.writeDecodeScalar(f.dec, f.elem, "set[i]", vals+"[i:i+1]", f.key, "elemErr")
+		g.printf("if elemErr != nil {")
+		g.printf("err = &form.ElemParseError{Index: i, Err: elemErr}")
+		g.printf("break")
+		g.printf("}")
+		g.printf("}")
+		g.printf("if err == nil {")
+		g.printf("%s = set", f.expr)
+		g.printf("}")
+	}
+}
+
+// writeDecodeScalar writes the statements that decode vals into dst and declare errName.
+// dst can be a dereferenced pointer such as *x.Count.
+func (g *generator) writeDecodeScalar(kind valueKind, t types.Type, dst, vals, key, errName string) {
+	// Methods are called on the pointer itself.
+	recv := strings.TrimPrefix(dst, "*")
+
+	var parse string
+	switch kind {
+	case kindFieldParser:
+		g.printf("%s := %s.ParseField(%q, %s)", errName, recv, key, vals)
+		return
+
+	case kindText:
+		g.printf("s, %s := form.ParseString(%s)", errName, vals)
+		g.printf("if %s == nil {", errName)
+		g.printf("%s = %s.UnmarshalText([]byte(s))", errName, recv)
+		g.printf("}")
+		return
+
+	case kindDuration:
+		parse = fmt.Sprintf("form.ParseDuration(%s)", vals)
+	case kindBool:
+		parse = fmt.Sprintf("form.ParseBool(%s)", vals)
+	case kindInt:
+		parse = fmt.Sprintf("form.ParseInt(%s, %d)", vals, intBits(t))
+	case kindUint:
+		parse = fmt.Sprintf("form.ParseUint(%s, %d)", vals, intBits(t))
+	case kindFloat:
+		parse = fmt.Sprintf("form.ParseFloat(%s, %d)", vals, floatBits(t))
+	case kindString:
+		parse = fmt.Sprintf("form.ParseString(%s)", vals)
+	}
+
+	g.printf("v, %s := %s", errName, parse)
+	g.printf("if %s == nil {", errName)
+	g.printf("%s = %s(v)", dst, g.typeString(t))
+	g.printf("}")
+}
+
+func floatBits(t types.Type) int {
+	if t.Underlying().(*types.Basic).Kind() == types.Float32 {
+		return 32
+	}
+
+	return 64
+}
+
+// intBits returns the bit size of an integer type for form.ParseInt and form.ParseUint. int
+// and uint are 0 as their size depends on the platform.
+func intBits(t types.Type) int {
+	switch t.Underlying().(*types.Basic).Kind() {
+	case types.Int8, types.Uint8:
+		return 8
+	case types.Int16, types.Uint16:
+		return 16
+	case types.Int32, types.Uint32:
+		return 32
+	case types.Int64, types.Uint64:
+		return 64
+	}
+
+	return 0
+}
+
+func decodeFunc(style string) string {
+	switch style {
+	case form.RecurseNestedMap:
+		return "NestedMapDecodeFunc"
+	case form.RecurseListMap:
+		return "ListMapDecodeFunc"
+	}
+
+	return "ListDecodeFunc"
+}
+
+// joinKeys joins the keys of a field in the same way as the Encoder.
+func (g *generator) joinKeys(parts []string) string {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+
+	switch g.opts.Recurse {
+	case form.RecurseNestedMap:
+		return form.NestedMapEncodeFunc(parts)
+	case form.RecurseListMap:
+		return form.ListMapEncodeFunc(parts)
+	}
+
+	return form.ListEncodeFunc(parts)
+}
+
+func (g *generator) writeEncode(name string, s *genStruct) {
+	g.printf("")
+	g.printf("// EncodeForm encodes x in the same way as a form.Encoder with the options from FormOptions")
+	g.printf("// and the default float format and location.")
+	g.printf("func (x %s) EncodeForm() (url.Values, error) {", name)
+	g.printf("vals := url.Values{}")
+	g.writeEncodeStruct(s)
+	g.printf("return vals, nil")
+	g.printf("}")
+}
+
+func (g *generator) writeEncodeStruct(s *genStruct) {
+	for _, f := range s.fields {
+		if f.shape == shapeStruct {
+			g.writeEncodeStruct(f.sub)
+			continue
+		}
+
+		// Nil pointers are always skipped.
+		cond := ""
+		if f.omitEmpty {
+			cond = notEmpty(f)
+		} else if f.shape == shapePointer {
+			cond = f.expr + " != nil"
+		}
+
+		if cond != "" {
+			g.printf("if %s {", cond)
+		} else {
+			g.printf("{")
+		}
+
+		key := g.joinKeys(f.parts)
+		switch f.shape {
+		case shapeScalar:
+			g.writeEncodeScalar(f.enc, f.elem, f.expr, "ev")
+
+		case shapePointer:
+			g.writeEncodeScalar(f.enc, f.elem, "*"+f.expr, "ev")
+
+		case shapeSlice:
+			g.printf("ev := make([]string, 0, len(%s))", f.expr)
+			g.printf("for i := range %s {", f.expr)
+			g.writeEncodeScalar(f.enc, f.elem, f.expr+"[i]", "elemVals")
+			g.printf("ev = append(ev, elemVals...)")
+			g.printf("}")
+		}
+
+		g.printf("vals[%q] = ev", key)
+		g.printf("}")
+	}
+}
+
+// notEmpty returns the condition for a field not being empty for the omitempty option. Types
+// with an IsZero method report whether they are empty themselves.
+func notEmpty(f *genField) string {
+	switch {
+	case f.shape == shapePointer && hasMethod(f.elem, "IsZero", nil, []string{"bool"}):
+		return f.expr + " != nil && !" + f.expr + ".IsZero()"
+	case f.shape == shapePointer:
+		return f.expr + " != nil"
+	case hasMethod(f.typ, "IsZero", nil, []string{"bool"}):
+		return "!" + f.expr + ".IsZero()"
+	case f.shape == shapeSlice:
+		return "len(" + f.expr + ") != 0"
+	}
+
+	basic, ok := f.typ.Underlying().(*types.Basic)
+	if !ok {
+		return "true"
+	}
+
+	switch info := basic.Info(); {
+	case info&types.IsBoolean != 0:
+		return f.expr
+	case info&types.IsString != 0:
+		return f.expr + ` != ""`
+	}
+
+	return f.expr + " != 0"
+}
+
+// writeEncodeScalar writes the statements that encode src into the []string named ev. src
+// can be a dereferenced pointer such as *x.Count.
+func (g *generator) writeEncodeScalar(kind valueKind, t types.Type, src, ev string) {
+	// Methods are called on the pointer itself.
+	recv := strings.TrimPrefix(src, "*")
+
+	var value string
+	switch kind {
+	case kindFieldEncoder:
+		g.printf("%s, err := %s.EncodeField()", ev, recv)
+		g.printf("if err != nil {")
+		g.printf("return nil, err")
+		g.printf("}")
+		return
+
+	case kindText:
+		g.printf("b, err := %s.MarshalText()", recv)
+		g.printf("if err != nil {")
+		g.printf("return nil, err")
+		g.printf("}")
+		value = "string(b)"
+
+	case kindDuration:
+		value = recv + ".String()"
+	case kindBool:
+		g.imports["strconv"] = true
+		value = fmt.Sprintf("strconv.FormatBool(bool(%s))", src)
+	case kindInt:
+		g.imports["strconv"] = true
+		value = fmt.Sprintf("strconv.FormatInt(int64(%s), 10)", src)
+	case kindUint:
+		g.imports["strconv"] = true
+		value = fmt.Sprintf("strconv.FormatUint(uint64(%s), 10)", src)
+	case kindFloat:
+		g.imports["strconv"] = true
+		value = fmt.Sprintf("strconv.FormatFloat(float64(%s), 'g', -1, %d)", src, floatBits(t))
+	case kindString:
+		value = fmt.Sprintf("string(%s)", src)
+	}
+
+	g.printf("%s := []string{%s}", ev, value)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattpgray/form"
+)
+
+func TestGenerateUpToDate(t *testing.T) {
+	dir := filepath.Join("..", "..", "internal", "gentest")
+	tests := []struct {
+		file string
+		typ  string
+		opts form.GeneratedOptions
+	}{
+		{"order_form.go", "Order", form.GeneratedOptions{StrictCase: false, Recurse: form.RecurseListMap}},
+		{"search_form.go", "Search", form.GeneratedOptions{StrictCase: true}},
+	}
+
+	pkg, err := loadPackage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		got, err := generatePackage(pkg, []string{test.typ}, test.opts)
+		if err != nil {
+			t.Fatalf("generate %s: %q", test.typ, err)
+		}
+
+		want, err := os.ReadFile(filepath.Join(dir, test.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date. Run go generate ./internal/gentest", test.file)
+		}
+	}
+}
+
+func TestGenerateUnsupported(t *testing.T) {
+	dir := filepath.Join("testdata", "unsupported")
+	tests := []struct {
+		typ  string
+		want string
+	}{
+		{"WithTime", "unsupported type time.Time"},
+		{"WithMap", "unsupported type map[string]string"},
+		{"WithValidate", "validate tags are not supported"},
+		{"WithSliceStyle", "slice styles are not supported"},
+		{"WithUnknownOption", `unknown option "requird"`},
+		{"WithStruct", "needs -recurse"},
+		{"WithDuplicate", "duplicate field a"},
+		{"WithFile", "unsupported file type github.com/mattpgray/form.File"},
+		{"WithFileHeaders", "unsupported file type []*mime/multipart.FileHeader"},
+		{"Missing", "type Missing not found"},
+	}
+
+	// Type checking the package is slow, so it is loaded once for every case.
+	pkg, err := loadPackage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		_, err := generatePackage(pkg, []string{test.typ}, form.GeneratedOptions{StrictCase: true})
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("Unexpected error for %s. Expected %q found %v", test.typ, test.want, err)
+		}
+	}
+}
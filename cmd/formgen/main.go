@@ -0,0 +1,83 @@
+// Formgen generates DecodeForm and EncodeForm methods for structs so that decoding and
+// encoding them does not need reflection. It is intended to be used with go:generate:
+//
+//	//go:generate go run github.com/mattpgray/form/cmd/formgen -type Order -recurse listmap
+//
+// The methods behave the same as a form.Decoder and form.Encoder with the options the code
+// was generated for, and the Decoder and Encoder use them automatically when their options
+// match. The flags are:
+//
+//	-type       comma separated list of struct types to generate methods for; required
+//	-output     output file name; default <type>_form.go
+//	-recurse    sub key style: nestedmap, listmap or list; default none
+//	-strictcase match form keys case sensitively; default true
+//
+// Fields must be strings, bools, numbers, time.Duration, types that implement both
+// form.FieldParser and form.FieldEncoder or both encoding.TextUnmarshaler and
+// encoding.TextMarshaler, pointers to or slices of those, or structs of those when -recurse
+// is set. Fields with validate tags are not supported. Formgen reports an error for any other
+// field rather than generating code that behaves differently.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattpgray/form"
+)
+
+var (
+	typeNames  = flag.String("type", "", "comma separated list of type names; required")
+	output     = flag.String("output", "", "output file name; default <type>_form.go")
+	recurse    = flag.String("recurse", "", "sub key style: nestedmap, listmap or list; default none")
+	strictCase = flag.Bool("strictcase", true, "match form keys case sensitively")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: formgen -type T [flags] [directory]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("formgen: ")
+	flag.Usage = usage
+	flag.Parse()
+
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	switch *recurse {
+	case "", form.RecurseNestedMap, form.RecurseListMap, form.RecurseList:
+	default:
+		log.Fatalf("unknown -recurse style %q", *recurse)
+	}
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	types := strings.Split(*typeNames, ",")
+	opts := form.GeneratedOptions{StrictCase: *strictCase, Recurse: *recurse}
+
+	src, err := generate(dir, types, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	name := *output
+	if name == "" {
+		name = strings.ToLower(types[0]) + "_form.go"
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, name), src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
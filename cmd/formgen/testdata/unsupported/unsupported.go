@@ -0,0 +1,45 @@
+package unsupported
+
+import (
+	"mime/multipart"
+	"time"
+
+	"github.com/mattpgray/form"
+)
+
+type WithTime struct {
+	At time.Time
+}
+
+type WithMap struct {
+	Attrs map[string]string
+}
+
+type WithValidate struct {
+	Age int `validate:"min=18"`
+}
+
+type WithSliceStyle struct {
+	IDs []int `form:"ids,slice=comma"`
+}
+
+type WithUnknownOption struct {
+	Name string `form:"name,requird"`
+}
+
+type WithStruct struct {
+	Inner struct{ A int }
+}
+
+type WithDuplicate struct {
+	A int `form:"a"`
+	B int `form:"a"`
+}
+
+type WithFile struct {
+	Avatar form.File
+}
+
+type WithFileHeaders struct {
+	Photos []*multipart.FileHeader
+}
@@ -1,9 +1,12 @@
 package form
 
 import (
+	"encoding"
 	"net/url"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // FieldParser allows callers to implement custom form parsing for a particular field
@@ -27,28 +30,70 @@ func EncodeString(v interface{}) (string, error) {
 type Encoder struct {
 	CaseInsensitive bool
 	AllowExtra      bool
-	// Recurse allows sub structs to be populated. Leave nil if you do not want sub keys to be parsed.
-	// If Recurse is nil and a sub struct/map is found then it is ignored.
+	// Recurse allows sub structs and maps to be encoded as sub keys. If Recurse is nil then
+	// encoding a sub struct or map returns a FieldTypeError. Slices and arrays that cannot be
+	// encoded as repeated values, such as slices of structs, use the index as the sub key. Use
+	// the EncodeSubKeyFunc that matches the DecodeSubKeyFunc of the Decoder, such as
+	// ListMapEncodeFunc with ListMapDecodeFunc, for the values to decode into the same struct.
 	Recurse EncodeSubKeyFunc
+	// FloatFormat is the format passed to strconv.FormatFloat and strconv.FormatComplex when
+	// encoding float and complex fields, e.g. 'f' or 'g'. If zero then the values are encoded
+	// with the 'g' format using the smallest precision that represents the value exactly
+	// and FloatPrecision is ignored.
+	FloatFormat byte
+	// FloatPrecision is the precision passed to strconv.FormatFloat and strconv.FormatComplex
+	// when FloatFormat is set. -1 uses the smallest number of digits necessary.
+	FloatPrecision int
+	// Location converts time values into the location before they are formatted. If nil then
+	// times are formatted in their own location.
+	Location *time.Location
+	// SliceStyle is the format of the values of slice and array fields. The slice option of
+	// the form tag overrides it for a single field. The default is SliceRepeat.
+	SliceStyle SliceStyle
+	// OmitZero leaves out every field that holds the zero value of its type, as if it had the
+	// omitempty option. Types that implement IsZero() bool, such as time.Time, report whether
+	// they are zero themselves. It keeps query strings short, such as for search links.
+	OmitZero bool
+
+	// files collects the file fields when encoding a multipart body. Files cannot be encoded
+	// if it is nil.
+	files *[]multipartFile
 }
 
 // Parse parses the form values into the supplied variable based on the parsers options.
 // The supplied value must be either a non-nil pointer to a struct or a map.
 func (p *Encoder) Encode(v interface{}) (url.Values, error) {
-	// TODO: Map
+	if gen, ok := p.generatedEncoder(v); ok {
+		return gen.EncodeForm()
+	}
 
 	vals := url.Values{}
 
 	ele := baseElem(reflect.ValueOf(v))
-	if err := addURLVals(vals, ele, nil, nil); err != nil {
-		return nil, err
-	} // if
+	switch ele.Kind() {
+	case reflect.Struct:
+		if err := p.addURLVals(vals, ele, nil); err != nil {
+			return nil, err
+		} // if
+
+	case reflect.Map:
+		if err := p.addMapURLVals(vals, ele, nil); err != nil {
+			if err == errUnsupportedType {
+				return nil, &EncodeTypeError{Type: reflect.TypeOf(v)}
+			}
+
+			return nil, err
+		} // if
+
+	default:
+		return nil, &EncodeTypeError{Type: reflect.TypeOf(v)}
+	}
 
 	return vals, nil
 }
 
 func (p *Encoder) EncodeString(v interface{}) (string, error) {
-	vals, err := Encode(v)
+	vals, err := p.Encode(v)
 	if err != nil {
 		return "", err
 	}
@@ -56,104 +101,328 @@ func (p *Encoder) EncodeString(v interface{}) (string, error) {
 	return vals.Encode(), nil
 }
 
-func addURLVals(vals url.Values, ele reflect.Value, prevKeys []string, recurse func(keys []string) string) error {
-	eleType := ele.Type()
-	nFields := ele.NumField()
+func (p *Encoder) addURLVals(vals url.Values, ele reflect.Value, prevKeys []string) error {
+	for _, field := range cachedTypeFields(ele.Type()) {
+		if field.opts.err != nil {
+			return field.opts.err
+		}
+
+		value := ele.FieldByIndex(field.index)
+		if p.omitValue(value, field.opts) {
+			continue
+		}
+
+		entry := baseElem(value)
+		name := field.opts.name
 
-	for i := 0; i < nFields; i++ {
-		entry := baseElem(ele.Field(i))
-		entryType := eleType.Field(i)
+		keys := appendKey(prevKeys, name)
+		if err := p.addValueURLVals(vals, entry, keys, field.opts); err != nil {
+			if err == errUnsupportedType {
+				return &FieldTypeError{Field: name, Type: entry.Type()}
+			}
+
+			// TODO: Meta info on error
+			return err
+		}
+	} // for
+
+	return nil
+}
+
+// addMapURLVals adds the entries of the map m to vals. The keys are encoded in the same way as
+// a single value.
+func (p *Encoder) addMapURLVals(vals url.Values, m reflect.Value, prevKeys []string) error {
+	iter := m.MapRange()
+	for iter.Next() {
+		keyVals, err := p.encodeScalar(iter.Key(), &fieldOptions{})
+		if err != nil {
+			return err
+		}
 
-		isUnexported := entryType.PkgPath != ""
-		if isUnexported {
+		if len(keyVals) != 1 {
+			return errUnsupportedType
+		}
+
+		elem := iter.Value()
+		if elem.Kind() == reflect.Interface {
+			elem = elem.Elem()
+		}
+
+		if isNilValue(elem) {
 			continue
 		}
 
-		name := entryType.Name
-		if tag := entryType.Tag.Get("form"); tag != "" {
-			name = tag
+		keys := appendKey(prevKeys, keyVals[0])
+		if err := p.addValueURLVals(vals, elem, keys, &fieldOptions{}); err != nil {
+			if err == errUnsupportedType {
+				return &FieldTypeError{Field: p.joinKeys(keys), Type: elem.Type()}
+			}
+
+			return err
 		}
+	}
 
-		if _, ok := vals[name]; ok {
-			return &DuplicateFieldError{Field: name}
+	return nil
+}
+
+// addValueURLVals adds v to vals under the joined keys. If v cannot be encoded as form values
+// and Recurse is set then structs, maps and slices are flattened into sub keys.
+func (p *Encoder) addValueURLVals(vals url.Values, v reflect.Value, keys []string, opts *fieldOptions) error {
+	if ok, err := p.addFiles(v, keys); ok {
+		return err
+	}
+
+	encVals, err := p.encodeScalar(v, opts)
+	if err == errUnsupportedType {
+		if encVals, err = p.encodeList(v, opts); err == nil {
+			return p.addListURLVals(vals, encVals, keys, opts)
 		}
+	}
+
+	if err == errUnsupportedType && p.Recurse != nil {
+		return p.addSubURLVals(vals, v, keys)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return setURLVals(vals, p.joinKeys(keys), encVals)
+}
+
+// addListURLVals adds the encoded elements of a slice or array to vals in the slice style of
+// the field.
+func (p *Encoder) addListURLVals(vals url.Values, encVals []string, keys []string, opts *fieldOptions) error {
+	style, err := sliceStyle(p.SliceStyle, opts)
+	if err != nil {
+		return err
+	}
+
+	switch style {
+	case SliceBrackets:
+		return setURLVals(vals, p.joinKeys(appendKey(keys, "")), encVals)
 
-		// Add anonymous structs values at the same level as the current
-		if entryType.Type.Kind() == reflect.Struct && entryType.Anonymous {
-			if err := addURLVals(vals, entry, prevKeys, recurse); err != nil {
+	case SliceIndexed:
+		for i := range encVals {
+			if err := setURLVals(vals, p.joinKeys(appendKey(keys, strconv.Itoa(i))), encVals[i:i+1]); err != nil {
 				return err
 			}
-			continue
 		}
 
-		// First custom encoding
-		if fieldEncoder, ok := getFieldEncoder(entry); ok {
-			v, err := fieldEncoder.EncodeField()
-			// TODO: Meta info on error
-			if err != nil {
-				return err
+		return nil
+
+	case SliceComma:
+		for _, v := range encVals {
+			if strings.Contains(v, ",") {
+				return &CommaValueError{Key: p.joinKeys(keys), Value: v}
 			}
+		}
+
+		if len(encVals) > 0 {
+			encVals = []string{strings.Join(encVals, ",")}
+		}
+	}
 
-			vals[name] = v
-		} else {
-			if recurse != nil && entryType.Type.Kind() == reflect.Struct {
-				nextKeys := append(prevKeys, name)
-				if err := addURLVals(vals, entry, nextKeys, recurse); err != nil {
-					return err
-				}
+	return setURLVals(vals, p.joinKeys(keys), encVals)
+}
+
+// setURLVals sets the values of name unless it has already been set by another field.
+func setURLVals(vals url.Values, name string, encVals []string) error {
+	if _, ok := vals[name]; ok {
+		return &DuplicateFieldError{Field: name}
+	}
 
+	vals[name] = encVals
+
+	return nil
+}
+
+// addSubURLVals adds the fields of a struct, the entries of a map or the elements of a slice
+// as sub keys of keys. Slice elements use their index as the sub key.
+func (p *Encoder) addSubURLVals(vals url.Values, v reflect.Value, keys []string) error {
+	v = baseElem(v)
+	switch v.Kind() {
+	case reflect.Struct:
+		return p.addURLVals(vals, v, keys)
+
+	case reflect.Map:
+		return p.addMapURLVals(vals, v, keys)
+
+	case reflect.Slice,
+		reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if isNilValue(v.Index(i)) {
 				continue
 			}
 
-			switch entry.Type().Kind() {
-			case reflect.Bool:
-				if entry.Bool() {
-					vals[name] = []string{"true"}
-				} else {
-					vals[name] = []string{"false"}
-				}
-
-			case reflect.Int,
-				reflect.Int8,
-				reflect.Int16,
-				reflect.Int32,
-				reflect.Int64:
-				vals[name] = []string{strconv.FormatInt(entry.Int(), 10)}
-
-			case reflect.Uint,
-				reflect.Uint8,
-				reflect.Uint16,
-				reflect.Uint32,
-				reflect.Uint64:
-				vals[name] = []string{strconv.FormatUint(entry.Uint(), 10)}
-
-			case reflect.Slice:
-				elem := entry.Type().Elem()
-				if elem.Kind() != reflect.String {
-					return &FieldTypeError{Field: name, Type: entry.Type()}
-				}
-
-				// Copy all of the elements into the new string type
-				// The inner string type has been aliased. We need to convert each element
-				nEntries := entry.Len()
-				set := make([]string, nEntries)
-				for i := 0; i < nEntries; i++ {
-					set[i] = entry.Index(i).String()
-				}
-
-				vals[name] = set
-
-			case reflect.String:
-				vals[name] = []string{entry.String()}
-
-			default:
-				return &FieldTypeError{Field: name, Type: entry.Type()}
+			if err := p.addValueURLVals(vals, v.Index(i), appendKey(keys, strconv.Itoa(i)), &fieldOptions{}); err != nil {
+				return err
 			}
 		}
 
-	} // for
+		return nil
+	}
 
-	return nil
+	return errUnsupportedType
+}
+
+// omitValue reports whether the field value v is left out of the form values. Nil pointers
+// and interfaces are always left out as they have no value to encode.
+func (p *Encoder) omitValue(v reflect.Value, opts *fieldOptions) bool {
+	if isNilValue(v) {
+		return true
+	}
+
+	if !opts.omitEmpty && !p.OmitZero {
+		return false
+	}
+
+	if zeroer, ok := getZeroer(v); ok {
+		return zeroer.IsZero()
+	}
+
+	if opts.omitEmpty && isEmptyValue(v) {
+		return true
+	}
+
+	return p.OmitZero && v.IsZero()
+}
+
+// isNilValue reports whether v is a nil pointer or interface, or points to one through any
+// number of pointers and interfaces. The zero Value of a nil interface is also nil.
+func isNilValue(v reflect.Value) bool {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return true
+		}
+
+		v = v.Elem()
+	}
+
+	return !v.IsValid()
+}
+
+// appendKey appends a key to a copy of keys so that sibling keys do not share the backing array.
+func appendKey(keys []string, key string) []string {
+	return append(keys[:len(keys):len(keys)], key)
+}
+
+// joinKeys joins the keys into one form key. Keys are only joined without Recurse for the
+// brackets and indexed slice styles, which then use the format of ListMapEncodeFunc.
+func (p *Encoder) joinKeys(keys []string) string {
+	if len(keys) == 1 {
+		return keys[0]
+	}
+
+	if p.Recurse == nil {
+		return ListMapEncodeFunc(keys)
+	}
+
+	return p.Recurse(keys)
+}
+
+// encodeList encodes the slice or array v element by element. It is used for values whose
+// type does not implement one of the encoding interfaces itself.
+func (p *Encoder) encodeList(v reflect.Value, opts *fieldOptions) ([]string, error) {
+	base := baseElem(v)
+	if base.Kind() != reflect.Slice && base.Kind() != reflect.Array {
+		return nil, errUnsupportedType
+	}
+
+	nEntries := base.Len()
+	set := make([]string, 0, nEntries)
+	for i := 0; i < nEntries; i++ {
+		if isNilValue(base.Index(i)) {
+			continue
+		}
+
+		elemVals, err := p.encodeScalar(base.Index(i), opts)
+		if err != nil {
+			return nil, err
+		}
+
+		set = append(set, elemVals...)
+	}
+
+	return set, nil
+}
+
+// encodeScalar encodes a single value.
+func (p *Encoder) encodeScalar(v reflect.Value, opts *fieldOptions) ([]string, error) {
+	// First custom encoding
+	if fieldEncoder, ok := getFieldEncoder(v); ok {
+		return fieldEncoder.EncodeField()
+	}
+
+	switch base := baseElem(v); base.Type() {
+	case timeType:
+		t := base.Interface().(time.Time)
+		if p.Location != nil {
+			t = t.In(p.Location)
+		}
+
+		return []string{formatTime(t, opts.layout)}, nil
+
+	case durationType:
+		return []string{time.Duration(base.Int()).String()}, nil
+	}
+
+	if textMarshaler, ok := getTextMarshaler(v); ok {
+		b, err := textMarshaler.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{string(b)}, nil
+	}
+
+	v = baseElem(v)
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return []string{"true"}, nil
+		}
+
+		return []string{"false"}, nil
+
+	case reflect.Int,
+		reflect.Int8,
+		reflect.Int16,
+		reflect.Int32,
+		reflect.Int64:
+		return []string{strconv.FormatInt(v.Int(), 10)}, nil
+
+	case reflect.Uint,
+		reflect.Uint8,
+		reflect.Uint16,
+		reflect.Uint32,
+		reflect.Uint64:
+		return []string{strconv.FormatUint(v.Uint(), 10)}, nil
+
+	case reflect.Float32,
+		reflect.Float64:
+		format, prec := p.floatFormat()
+		return []string{strconv.FormatFloat(v.Float(), format, prec, v.Type().Bits())}, nil
+
+	case reflect.Complex64,
+		reflect.Complex128:
+		format, prec := p.floatFormat()
+		return []string{strconv.FormatComplex(v.Complex(), format, prec, v.Type().Bits())}, nil
+
+	case reflect.String:
+		return []string{v.String()}, nil
+	}
+
+	return nil, errUnsupportedType
+}
+
+func (p *Encoder) floatFormat() (byte, int) {
+	if p.FloatFormat == 0 {
+		return 'g', -1
+	}
+
+	return p.FloatFormat, p.FloatPrecision
 }
 
 func getFieldEncoder(v reflect.Value) (FieldEncoder, bool) {
@@ -188,3 +457,83 @@ func getFieldEncoderOnce(v reflect.Value) (FieldEncoder, bool) {
 
 	return nil, false
 }
+
+// getTextMarshaler looks for an encoding.TextMarshaler in the same way as getFieldEncoder.
+func getTextMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if textMarshaler, ok := getTextMarshalerOnce(v); ok {
+		return textMarshaler, true
+	}
+
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		if textMarshaler, ok := getTextMarshalerOnce(v.Elem()); ok {
+			return textMarshaler, true
+		}
+	}
+
+	if v.CanAddr() {
+		if textMarshaler, ok := getTextMarshalerOnce(v.Addr()); ok {
+			return textMarshaler, true
+		}
+	}
+
+	return nil, false
+}
+
+func getTextMarshalerOnce(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if v.CanInterface() {
+		if textMarshaler, ok := v.Interface().(encoding.TextMarshaler); ok {
+			return textMarshaler, true
+		}
+	} // if
+
+	return nil, false
+}
+
+// zeroer is implemented by types that report whether they are zero, such as time.Time.
+type zeroer interface {
+	IsZero() bool
+}
+
+// getZeroer looks for a zeroer in the same way as getFieldEncoder.
+func getZeroer(v reflect.Value) (zeroer, bool) {
+	if zeroer, ok := getZeroerOnce(v); ok {
+		return zeroer, true
+	}
+
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		if zeroer, ok := getZeroerOnce(v.Elem()); ok {
+			return zeroer, true
+		}
+	}
+
+	if v.CanAddr() {
+		if zeroer, ok := getZeroerOnce(v.Addr()); ok {
+			return zeroer, true
+		}
+	}
+
+	return nil, false
+}
+
+func getZeroerOnce(v reflect.Value) (zeroer, bool) {
+	if v.CanInterface() {
+		if zeroer, ok := v.Interface().(zeroer); ok {
+			return zeroer, true
+		}
+	}
+
+	return nil, false
+}
+
+// EncodeTypeError is returned when the value passed to Encode is not a struct or a map.
+type EncodeTypeError struct {
+	Type reflect.Type
+}
+
+func (e *EncodeTypeError) Error() string {
+	if e.Type == nil {
+		return buildErrorMessage("Encode", "nil")
+	}
+
+	return buildErrorMessage("Encode", "invalid type "+e.Type.String())
+}
@@ -1,8 +1,15 @@
 package form
 
 import (
+	"bytes"
+	"errors"
 	"log"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestEncode(t *testing.T) {
@@ -19,3 +26,562 @@ func TestEncode(t *testing.T) {
 
 	log.Printf("%s", s)
 }
+
+func TestEncodeFloat(t *testing.T) {
+	testStruct := struct {
+		Float32    float32
+		Float64    float64
+		Complex128 complex128
+	}{
+		Float32:    0.1,
+		Float64:    1234.5678,
+		Complex128: 1 + 2i,
+	}
+
+	vals, err := Encode(testStruct)
+	if err != nil {
+		t.Fatalf("Encode: %q", err)
+	}
+
+	want := url.Values{
+		"Float32":    []string{"0.1"},
+		"Float64":    []string{"1234.5678"},
+		"Complex128": []string{"(1+2i)"},
+	}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Unexpected values. Expected %v found %v", want, vals)
+	}
+
+	e := &Encoder{FloatFormat: 'f', FloatPrecision: 2}
+	vals, err = e.Encode(testStruct)
+	if err != nil {
+		t.Fatalf("Encode: %q", err)
+	}
+
+	want = url.Values{
+		"Float32":    []string{"0.10"},
+		"Float64":    []string{"1234.57"},
+		"Complex128": []string{"(1.00+2.00i)"},
+	}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Unexpected values. Expected %v found %v", want, vals)
+	}
+}
+
+type lowerText string
+
+func (l lowerText) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(string(l))), nil
+}
+
+func TestEncodeTextMarshaler(t *testing.T) {
+	testStruct := struct {
+		IP    net.IP
+		Lower lowerText
+	}{
+		IP:    net.IPv4(10, 0, 0, 1),
+		Lower: "LOWER",
+	}
+
+	vals, err := Encode(testStruct)
+	if err != nil {
+		t.Fatalf("Encode: %q", err)
+	}
+
+	want := url.Values{
+		"IP":    []string{"10.0.0.1"},
+		"Lower": []string{"lower"},
+	}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Unexpected values. Expected %v found %v", want, vals)
+	}
+}
+
+func TestEncodeScalarSlice(t *testing.T) {
+	type Enum int
+	testStruct := struct {
+		IDs    []int64
+		Flags  []bool
+		Enums  []Enum
+		Lowers []lowerText
+	}{
+		IDs:    []int64{1, 2},
+		Flags:  []bool{true},
+		Enums:  []Enum{3},
+		Lowers: []lowerText{"A", "B"},
+	}
+
+	vals, err := Encode(testStruct)
+	if err != nil {
+		t.Fatalf("Encode: %q", err)
+	}
+
+	want := url.Values{
+		"IDs":    []string{"1", "2"},
+		"Flags":  []string{"true"},
+		"Enums":  []string{"3"},
+		"Lowers": []string{"a", "b"},
+	}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Unexpected values. Expected %v found %v", want, vals)
+	}
+}
+
+func TestEncodeArray(t *testing.T) {
+	testStruct := struct {
+		RGB [3]uint8
+	}{
+		RGB: [3]uint8{255, 128, 0},
+	}
+
+	vals, err := Encode(testStruct)
+	if err != nil {
+		t.Fatalf("Encode: %q", err)
+	}
+
+	want := url.Values{
+		"RGB": []string{"255", "128", "0"},
+	}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Unexpected values. Expected %v found %v", want, vals)
+	}
+}
+
+func TestEncodeMap(t *testing.T) {
+	vals, err := Encode(map[string]string{"a": "1", "b": "2"})
+	if err != nil {
+		t.Fatalf("Encode: %q", err)
+	}
+
+	want := url.Values{
+		"a": []string{"1"},
+		"b": []string{"2"},
+	}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Unexpected values. Expected %v found %v", want, vals)
+	}
+
+	vals, err = Encode(&map[string][]string{"a": {"1", "2"}})
+	if err != nil {
+		t.Fatalf("Encode: %q", err)
+	}
+
+	want = url.Values{
+		"a": []string{"1", "2"},
+	}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Unexpected values. Expected %v found %v", want, vals)
+	}
+
+	nested := map[string]interface{}{
+		"a": "1",
+		"b": []string{"2", "3"},
+		"c": map[string]interface{}{
+			"d": 4,
+			"e": map[string]interface{}{
+				"f": []string{"5", "6"},
+			},
+		},
+	}
+
+	e := &Encoder{Recurse: NestedMapEncodeFunc}
+	vals, err = e.Encode(nested)
+	if err != nil {
+		t.Fatalf("Encode: %q", err)
+	}
+
+	want = url.Values{
+		"a":       []string{"1"},
+		"b":       []string{"2", "3"},
+		"c[d]":    []string{"4"},
+		"c[e[f]]": []string{"5", "6"},
+	}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Unexpected values. Expected %v found %v", want, vals)
+	}
+
+	vals, err = Encode(map[string]interface{}{"a": (*int)(nil), "b": nil, "c": "1"})
+	if err != nil {
+		t.Fatalf("Encode: %q", err)
+	}
+
+	want = url.Values{
+		"c": []string{"1"},
+	}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Unexpected values. Expected %v found %v", want, vals)
+	}
+
+	var fieldTypeErr *FieldTypeError
+	if _, err := Encode(nested); !errors.As(err, &fieldTypeErr) {
+		t.Errorf("Encode not a field type error: %q", err)
+	}
+
+	var encodeTypeErr *EncodeTypeError
+	if _, err := Encode(1); !errors.As(err, &encodeTypeErr) {
+		t.Errorf("Encode not an encode type error: %q", err)
+	}
+}
+
+func TestEncodeIndexedSlice(t *testing.T) {
+	type Item struct {
+		SKU string `form:"sku"`
+		Qty int    `form:"qty"`
+	}
+
+	testStruct := struct {
+		Items []Item  `form:"items"`
+		Ptrs  []*Item `form:"ptrs"`
+	}{
+		Items: []Item{{SKU: "A", Qty: 2}, {SKU: "B", Qty: 1}},
+		Ptrs:  []*Item{{SKU: "C", Qty: 3}},
+	}
+
+	e := &Encoder{Recurse: ListMapEncodeFunc}
+	vals, err := e.Encode(testStruct)
+	if err != nil {
+		t.Fatalf("Encode: %q", err)
+	}
+
+	want := url.Values{
+		"items[0][sku]": []string{"A"},
+		"items[0][qty]": []string{"2"},
+		"items[1][sku]": []string{"B"},
+		"items[1][qty]": []string{"1"},
+		"ptrs[0][sku]":  []string{"C"},
+		"ptrs[0][qty]":  []string{"3"},
+	}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Unexpected values. Expected %v found %v", want, vals)
+	}
+
+	var fieldTypeErr *FieldTypeError
+	if _, err := Encode(testStruct); !errors.As(err, &fieldTypeErr) {
+		t.Errorf("Encode not a field type error: %q", err)
+	}
+}
+
+func TestEncodeTagOptions(t *testing.T) {
+	testStruct := struct {
+		Name   string   `form:"name"`
+		Page   int      `form:"page,omitempty"`
+		Query  string   `form:",omitempty"`
+		Tags   []string `form:"tags,omitempty"`
+		Ptr    *int     `form:"ptr,omitempty"`
+		Secret string   `form:"-"`
+		Sort   string   `form:"sort,default=name"`
+	}{
+		Query:  "q",
+		Secret: "s",
+	}
+
+	vals, err := Encode(testStruct)
+	if err != nil {
+		t.Fatalf("Encode: %q", err)
+	}
+
+	want := url.Values{
+		"name":  []string{""},
+		"Query": []string{"q"},
+		"sort":  []string{""},
+	}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Unexpected values. Expected %v found %v", want, vals)
+	}
+
+	var optionErr *TagOptionError
+	if _, err := Encode(struct {
+		Page int `form:"page,omitempy"`
+	}{}); !errors.As(err, &optionErr) {
+		t.Errorf("Encode not a tag option error: %q", err)
+	}
+}
+
+// pageToken reports the first page as zero so that it is left out of links.
+type pageToken string
+
+func (p pageToken) IsZero() bool {
+	return p == "" || p == "first"
+}
+
+func TestEncodeNilPointers(t *testing.T) {
+	type Address struct {
+		Street string `form:"street"`
+	}
+
+	n := 1
+	var nilPtr *int
+	testStruct := struct {
+		Count    *int            `form:"count"`
+		Shipping *Address        `form:"shipping"`
+		Any      interface{}     `form:"any"`
+		Ptrs     []*int          `form:"ptrs"`
+		Items    []*Address      `form:"items"`
+		Named    map[string]*int `form:"named"`
+		Avatar   *File           `form:"avatar"`
+		Created  *time.Time      `form:"created"`
+		Text     *net.IP         `form:"text"`
+		Lower    *lowerText      `form:"lower"`
+		PP       **int           `form:"pp"`
+		Typed    interface{}     `form:"typed"`
+	}{
+		Ptrs:  []*int{nil, &n},
+		Items: []*Address{nil, {Street: "1 Main St"}},
+		Named: map[string]*int{"a": nil, "b": &n},
+		PP:    &nilPtr,
+		Typed: (*int)(nil),
+	}
+
+	e := &Encoder{Recurse: ListMapEncodeFunc}
+	vals, err := e.Encode(&testStruct)
+	if err != nil {
+		t.Fatalf("Encode: %q", err)
+	}
+
+	want := url.Values{
+		"ptrs":             []string{"1"},
+		"items[1][street]": []string{"1 Main St"},
+		"named[b]":         []string{"1"},
+	}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Unexpected values. Expected %v found %v", want, vals)
+	}
+
+	var buf bytes.Buffer
+	if _, err := e.EncodeMultipart(&buf, &testStruct); err != nil {
+		t.Errorf("EncodeMultipart: %q", err)
+	}
+}
+
+func TestEncodeOmitZero(t *testing.T) {
+	type Address struct {
+		Street string `form:"street"`
+	}
+
+	zero := 0
+	testStruct := struct {
+		Query   string            `form:"q"`
+		Page    int               `form:"page"`
+		Count   *int              `form:"count"`
+		Tags    []string          `form:"tags"`
+		Pair    [2]int            `form:"pair"`
+		Billing Address           `form:"billing"`
+		Created time.Time         `form:"created"`
+		After   pageToken         `form:"after"`
+		Before  pageToken         `form:"before"`
+		Attrs   map[string]string `form:"attrs"`
+	}{
+		Query:  "shoes",
+		Count:  &zero,
+		After:  "first",
+		Before: "abc",
+	}
+
+	vals, err := (&Encoder{Recurse: ListMapEncodeFunc, OmitZero: true}).Encode(testStruct)
+	if err != nil {
+		t.Fatalf("Encode: %q", err)
+	}
+
+	want := url.Values{
+		"q":      []string{"shoes"},
+		"count":  []string{"0"},
+		"before": []string{"abc"},
+	}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Unexpected values. Expected %v found %v", want, vals)
+	}
+
+	// Without OmitZero only omitempty fields use IsZero.
+	omitEmpty := struct {
+		Created time.Time `form:"created,omitempty"`
+		After   pageToken `form:"after,omitempty"`
+		Before  pageToken `form:"before"`
+	}{
+		After:  "first",
+		Before: "first",
+	}
+
+	vals, err = Encode(omitEmpty)
+	if err != nil {
+		t.Fatalf("Encode: %q", err)
+	}
+
+	want = url.Values{
+		"before": []string{"first"},
+	}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Unexpected values. Expected %v found %v", want, vals)
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	src := struct {
+		Name    string   `form:"name"`
+		Email   string   `form:"email,omitempty"`
+		Age     int      `form:"age"`
+		Price   float64  `form:"price"`
+		Tags    []string `form:"tags"`
+		IDs     []int64  `form:"ids"`
+		Agree   bool     `form:"agree"`
+		Billing struct {
+			Street string `form:"street"`
+			Zip    string `form:"zip"`
+		} `form:"billing"`
+	}{
+		Name:  "Jo",
+		Age:   30,
+		Price: 9.99,
+		Tags:  []string{"a", "b"},
+		IDs:   []int64{1, 2, 3},
+		Agree: true,
+	}
+
+	e := &Encoder{Recurse: ListMapEncodeFunc}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := e.Encode(src); err != nil {
+			b.Fatalf("Encode: %q", err)
+		}
+	}
+}
+
+type generatedEncodeForm struct {
+	Name string
+}
+
+func (f generatedEncodeForm) EncodeForm() (url.Values, error) {
+	return url.Values{"generated": []string{f.Name}}, nil
+}
+
+func (generatedEncodeForm) FormOptions() GeneratedOptions {
+	return GeneratedOptions{StrictCase: true}
+}
+
+func TestEncodeGenerated(t *testing.T) {
+	src := generatedEncodeForm{Name: "n"}
+	generated := url.Values{"generated": []string{"n"}}
+	reflected := url.Values{"Name": []string{"n"}}
+
+	tests := []struct {
+		name string
+		e    *Encoder
+		want url.Values
+	}{
+		{"matching", &Encoder{}, generated},
+		{"recurse", &Encoder{Recurse: ListMapEncodeFunc}, reflected},
+		{"float format", &Encoder{FloatFormat: 'f'}, reflected},
+		{"location", &Encoder{Location: time.UTC}, reflected},
+	}
+
+	for _, test := range tests {
+		vals, err := test.e.Encode(&src)
+		if err != nil {
+			t.Fatalf("Encode %s: %q", test.name, err)
+		}
+
+		if !reflect.DeepEqual(vals, test.want) {
+			t.Errorf("Unexpected values for %s. Expected %v found %v", test.name, test.want, vals)
+		}
+	}
+}
+
+func TestEncodeRecurseRoundTrip(t *testing.T) {
+	type Address struct {
+		Street string `form:"street"`
+		Zip    string `form:"zip"`
+	}
+
+	type Item struct {
+		SKU   string            `form:"sku"`
+		Qty   int               `form:"qty"`
+		Tags  []string          `form:"tags"`
+		Attrs map[string]string `form:"attrs"`
+	}
+
+	type Meta struct {
+		Source string `form:"source"`
+	}
+
+	type Order struct {
+		Meta
+		Name     string              `form:"name"`
+		Codes    []int               `form:"codes"`
+		Pair     [2]string           `form:"pair"`
+		Billing  Address             `form:"billing"`
+		Shipping *Address            `form:"shipping"`
+		Items    []Item              `form:"items"`
+		Ptrs     []*Item             `form:"ptrs"`
+		Fixed    [2]Item             `form:"fixed"`
+		Named    map[string]Item     `form:"named"`
+		Counts   map[string]int      `form:"counts"`
+		Nested   map[string][]Item   `form:"nested"`
+		Addr     net.IP              `form:"addr"`
+		Created  time.Time           `form:"created"`
+		Timeout  time.Duration       `form:"timeout"`
+		Deep     struct{ A Address } `form:"deep"`
+	}
+
+	src := Order{
+		Meta:     Meta{Source: "web"},
+		Name:     "order",
+		Codes:    []int{1, 2},
+		Pair:     [2]string{"a", "b"},
+		Billing:  Address{Street: "1 Main St", Zip: "12345"},
+		Shipping: &Address{Street: "2 High St", Zip: "67890"},
+		Items: []Item{
+			{SKU: "A", Qty: 2, Tags: []string{"x", "y"}, Attrs: map[string]string{"colour": "red"}},
+			{SKU: "B", Qty: 1},
+		},
+		Ptrs:    []*Item{{SKU: "C", Qty: 3}},
+		Fixed:   [2]Item{{SKU: "D"}, {SKU: "E", Qty: 5}},
+		Named:   map[string]Item{"first": {SKU: "F", Qty: 6}},
+		Counts:  map[string]int{"a": 1, "b": 2},
+		Nested:  map[string][]Item{"g": {{SKU: "G"}, {SKU: "H"}}},
+		Addr:    net.ParseIP("10.0.0.1"),
+		Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Timeout: 90 * time.Second,
+	}
+	src.Deep.A = Address{Street: "3 Low St"}
+
+	tests := []struct {
+		name string
+		enc  EncodeSubKeyFunc
+		dec  DecodeSubKeyFunc
+		key  string
+	}{
+		{"nestedmap", NestedMapEncodeFunc, NestedMapDecodeFunc, "items[0[attrs[colour]]]"},
+		{"listmap", ListMapEncodeFunc, ListMapDecodeFunc, "items[0][attrs][colour]"},
+		{"list", ListEncodeFunc, ListDecodeFunc, "items.0.attrs.colour"},
+	}
+
+	for _, test := range tests {
+		e := &Encoder{Recurse: test.enc}
+		encoded, err := e.EncodeString(&src)
+		if err != nil {
+			t.Fatalf("Encode %s: %q", test.name, err)
+		}
+
+		vals, err := url.ParseQuery(encoded)
+		if err != nil {
+			t.Fatalf("ParseQuery %s: %q", test.name, err)
+		}
+
+		if got := vals.Get(test.key); got != "red" {
+			t.Errorf("Unexpected value in %s for %s. Expected \"red\" found %q", test.key, test.name, got)
+		}
+
+		d := NewDecoder()
+		d.Recurse(test.dec)
+		d.DisallowUnknownFields(true)
+
+		var dst Order
+		if err := d.Decode(vals, &dst); err != nil {
+			t.Fatalf("Decode %s: %q", test.name, err)
+		}
+
+		if !reflect.DeepEqual(dst, src) {
+			t.Errorf("Unexpected round trip for %s.\nExpected %+v\nfound    %+v\nvalues %v", test.name, src, dst, vals)
+		}
+	}
+}
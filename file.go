@@ -0,0 +1,258 @@
+package form
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// File is an uploaded file. It can be used for file fields instead of *multipart.FileHeader
+// when the content type should be checked or the field should not depend on the multipart
+// package.
+//
+// File fields, and *multipart.FileHeader fields, are set from the file parts of a multipart
+// form by DecodeMultipart and DecodeRequest. Slices of either hold every file submitted with
+// the key. The file tag limits the files that are accepted:
+//
+//	Avatar form.File   `file:"maxsize=1048576,accept=image/png image/jpeg"`
+//	Photos []form.File `file:"maxcount=10,accept=image/*"`
+//
+// maxsize is the largest file in bytes and maxcount is the most files for a slice field.
+// accept is a space separated list of media types, which can end in /* to match any sub type.
+// The content type is detected from the content of the file rather than trusted from the
+// client. Files that are not accepted return a FileSizeError, FileCountError or FileTypeError.
+type File struct {
+	// Name is the name of the file sent by the client.
+	Name string
+	// Size is the size of the file in bytes.
+	Size int64
+	// ContentType is detected from the first 512 bytes of the file with
+	// http.DetectContentType.
+	ContentType string
+	// Open opens the content of the file.
+	Open func() (io.ReadCloser, error)
+}
+
+var (
+	fileType          = reflect.TypeOf(File{})
+	fileHeaderPtrType = reflect.TypeOf(&multipart.FileHeader{})
+)
+
+// isFileType reports whether t is set from the files of a multipart form rather than the
+// values.
+func isFileType(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	return t == fileHeaderPtrType || baseType(t) == fileType
+}
+
+// DecodeMultipart decodes a multipart form into dst in the same way as Decode. The values of
+// the form are decoded as form values and the files are decoded into File and
+// *multipart.FileHeader fields using the same keys.
+func (d *Decoder) DecodeMultipart(src *multipart.Form, dst interface{}) error {
+	return d.decode(src.Value, src.File, dst)
+}
+
+// decodeFormVal decodes the values of a single key into v. File fields are set from the
+// files and other fields from the values. Keys that only have files are ignored by fields
+// that are not file fields.
+func (d *Decoder) decodeFormVal(v reflect.Value, key string, val *formVal, opts *fieldOptions) error {
+	if isFileType(v.Type()) {
+		return d.decodeFiles(v, val.files, opts)
+	}
+
+	if val.vals == nil && val.files != nil {
+		return nil
+	}
+
+	return d.decodeValue(v, key, val.vals, opts)
+}
+
+// decodeFiles sets the file field v from the uploaded files. The field is left unchanged if
+// there are no files, such as when a file input was left empty.
+func (d *Decoder) decodeFiles(v reflect.Value, files []*multipart.FileHeader, opts *fieldOptions) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	limits := opts.fileLimits
+	if v.Kind() != reflect.Slice {
+		if len(files) > 1 {
+			return &FileCountError{Max: 1, Got: len(files)}
+		}
+
+		return limits.setFile(v, files[0])
+	}
+
+	if limits.maxCount > 0 && len(files) > limits.maxCount {
+		return &FileCountError{Max: limits.maxCount, Got: len(files)}
+	}
+
+	set := reflect.MakeSlice(v.Type(), len(files), len(files))
+	for i, fh := range files {
+		if err := limits.setFile(set.Index(i), fh); err != nil {
+			return &ElemParseError{Index: i, Err: err}
+		}
+	}
+
+	v.Set(set)
+
+	return nil
+}
+
+// fileLimits are the limits read from the file tag.
+type fileLimits struct {
+	maxSize  int64
+	maxCount int
+	accept   []string
+}
+
+// readFileLimits reads the limits from the rules of a file tag. It is called once for each
+// field when its options are read.
+func readFileLimits(rules []fieldRule) (fileLimits, error) {
+	var limits fileLimits
+	for _, r := range rules {
+		var err error
+		switch r.name {
+		case "maxsize":
+			limits.maxSize, err = strconv.ParseInt(r.param, 10, 64)
+
+		case "maxcount":
+			limits.maxCount, err = strconv.Atoi(r.param)
+
+		case "accept":
+			limits.accept = strings.Fields(r.param)
+
+		default:
+			return limits, fmt.Errorf("%w %q", errUnknownFileLimit, r.name)
+		}
+
+		if err != nil {
+			return limits, fmt.Errorf("%w %s %q", errInvalidFileLimit, r.name, r.param)
+		}
+	}
+
+	return limits, nil
+}
+
+// The errors for file tags that cannot be read. They are mistakes in the struct rather than
+// the form.
+var (
+	errUnknownFileLimit = errors.New("unknown file limit")
+	errInvalidFileLimit = errors.New("invalid file limit")
+)
+
+// setFile checks the file against the limits and sets v to it.
+func (l fileLimits) setFile(v reflect.Value, fh *multipart.FileHeader) error {
+	if l.maxSize > 0 && fh.Size > l.maxSize {
+		return &FileSizeError{Filename: fh.Filename, Size: fh.Size, Max: l.maxSize}
+	}
+
+	isHeader := v.Type() == fileHeaderPtrType
+
+	var contentType string
+	if !isHeader || len(l.accept) > 0 {
+		var err error
+		if contentType, err = detectContentType(fh); err != nil {
+			return err
+		}
+
+		if len(l.accept) > 0 && !acceptsContentType(l.accept, contentType) {
+			return &FileTypeError{Filename: fh.Filename, ContentType: contentType, Accept: l.accept}
+		}
+	}
+
+	if isHeader {
+		v.Set(reflect.ValueOf(fh))
+		return nil
+	}
+
+	allocElem(v)
+	baseElem(v).Set(reflect.ValueOf(File{
+		Name:        fh.Filename,
+		Size:        fh.Size,
+		ContentType: contentType,
+		Open: func() (io.ReadCloser, error) {
+			return fh.Open()
+		},
+	}))
+
+	return nil
+}
+
+// detectContentType sniffs the content type of an uploaded file.
+func detectContentType(fh *multipart.FileHeader) (string, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var buf [512]byte
+	n, err := io.ReadFull(f, buf[:])
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// acceptsContentType reports whether the content type matches one of the accepted media
+// types. Parameters such as the charset are ignored.
+func acceptsContentType(accept []string, contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+
+	for _, a := range accept {
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if strings.EqualFold(a, mediaType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FileSizeError is returned when an uploaded file is larger than the maxsize of its field.
+type FileSizeError struct {
+	Filename string
+	Size     int64
+	Max      int64
+}
+
+func (e *FileSizeError) Error() string {
+	return buildErrorMessage("Parse", fmt.Sprintf("file %q is %d bytes, larger than %d", e.Filename, e.Size, e.Max))
+}
+
+// FileCountError is returned when more files are uploaded than the maxcount of a slice field,
+// or more than one file is uploaded for a single file field.
+type FileCountError struct {
+	Max int
+	Got int
+}
+
+func (e *FileCountError) Error() string {
+	return buildErrorMessage("Parse", fmt.Sprintf("expected at most %d files, found %d", e.Max, e.Got))
+}
+
+// FileTypeError is returned when the detected content type of an uploaded file is not one
+// of the accepted types of its field.
+type FileTypeError struct {
+	Filename    string
+	ContentType string
+	Accept      []string
+}
+
+func (e *FileTypeError) Error() string {
+	return buildErrorMessage("Parse", fmt.Sprintf("file %q has content type %q, expected one of %s", e.Filename, e.ContentType, strings.Join(e.Accept, ", ")))
+}
@@ -0,0 +1,263 @@
+package form
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const pngHeader = "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+
+type testPart struct {
+	key, filename, content string
+}
+
+// newMultipart builds a multipart body from the parts. Parts without a filename are values.
+func newMultipart(t *testing.T, parts ...testPart) (body *bytes.Buffer, contentType string) {
+	body = &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	for _, p := range parts {
+		if p.filename == "" {
+			mw.WriteField(p.key, p.content)
+			continue
+		}
+
+		w, err := mw.CreateFormFile(p.key, p.filename)
+		if err != nil {
+			t.Fatalf("CreateFormFile: %q", err)
+		}
+
+		io.WriteString(w, p.content)
+	}
+
+	if err := mw.Close(); err != nil {
+		t.Fatalf("Close: %q", err)
+	}
+
+	return body, mw.FormDataContentType()
+}
+
+func readMultipart(t *testing.T, parts ...testPart) *multipart.Form {
+	body, contentType := newMultipart(t, parts...)
+
+	r := httptest.NewRequest(http.MethodPost, "/", body)
+	r.Header.Set("Content-Type", contentType)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatalf("ParseMultipartForm: %q", err)
+	}
+
+	return r.MultipartForm
+}
+
+func readFile(t *testing.T, f File) string {
+	rc, err := f.Open()
+	if err != nil {
+		t.Fatalf("Open %s: %q", f.Name, err)
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll %s: %q", f.Name, err)
+	}
+
+	return string(b)
+}
+
+func TestDecodeMultipart(t *testing.T) {
+	type Profile struct {
+		Avatar *File
+	}
+
+	var dst struct {
+		Name    string
+		Avatar  File
+		Header  *multipart.FileHeader
+		Headers []*multipart.FileHeader
+		Photos  []File
+		Profile Profile
+	}
+
+	mf := readMultipart(t,
+		testPart{key: "Name", content: "name"},
+		testPart{key: "Avatar", filename: "avatar.png", content: pngHeader},
+		testPart{key: "Header", filename: "header.txt", content: "header"},
+		testPart{key: "Headers", filename: "a.txt", content: "a"},
+		testPart{key: "Headers", filename: "b.txt", content: "b"},
+		testPart{key: "Photos", filename: "c.txt", content: "c"},
+		testPart{key: "Profile[Avatar]", filename: "profile.txt", content: "profile"},
+	)
+
+	d := NewDecoder()
+	d.Recurse(ListMapDecodeFunc)
+	if err := d.DecodeMultipart(mf, &dst); err != nil {
+		t.Fatalf("DecodeMultipart: %q", err)
+	}
+
+	if dst.Name != "name" {
+		t.Errorf("Unexpected value in Name. Expected \"name\" found %q", dst.Name)
+	}
+
+	if dst.Avatar.Name != "avatar.png" || dst.Avatar.Size != int64(len(pngHeader)) || dst.Avatar.ContentType != "image/png" {
+		t.Errorf("Unexpected value in Avatar. Expected avatar.png, %d bytes, image/png found %s, %d bytes, %s", len(pngHeader), dst.Avatar.Name, dst.Avatar.Size, dst.Avatar.ContentType)
+	}
+
+	if got := readFile(t, dst.Avatar); got != pngHeader {
+		t.Errorf("Unexpected content in Avatar. Expected %q found %q", pngHeader, got)
+	}
+
+	if dst.Header == nil || dst.Header.Filename != "header.txt" {
+		t.Errorf("Unexpected value in Header. Expected header.txt found %v", dst.Header)
+	}
+
+	if len(dst.Headers) != 2 || dst.Headers[0].Filename != "a.txt" || dst.Headers[1].Filename != "b.txt" {
+		t.Errorf("Unexpected value in Headers. Expected [a.txt b.txt] found %v", dst.Headers)
+	}
+
+	if len(dst.Photos) != 1 || readFile(t, dst.Photos[0]) != "c" {
+		t.Errorf("Unexpected value in Photos. Expected [c.txt] found %v", dst.Photos)
+	}
+
+	if dst.Profile.Avatar == nil || dst.Profile.Avatar.ContentType != "text/plain; charset=utf-8" {
+		t.Errorf("Unexpected value in Profile.Avatar. Expected text/plain found %v", dst.Profile.Avatar)
+	}
+}
+
+func TestDecodeMultipartLimits(t *testing.T) {
+	type Upload struct {
+		Avatar File   `file:"maxsize=16,accept=image/*"`
+		Docs   []File `file:"maxcount=2,accept=text/plain application/pdf"`
+		Single *multipart.FileHeader
+		Name   string
+	}
+
+	tests := []struct {
+		name  string
+		parts []testPart
+		check func(err error) bool
+	}{
+		{
+			name:  "size",
+			parts: []testPart{{key: "Avatar", filename: "a.png", content: pngHeader + "0123456789"}},
+			check: func(err error) bool {
+				var sizeErr *FileSizeError
+				return errors.As(err, &sizeErr) && sizeErr.Max == 16 && sizeErr.Filename == "a.png"
+			},
+		},
+		{
+			name:  "type",
+			parts: []testPart{{key: "Avatar", filename: "a.png", content: "not a png"}},
+			check: func(err error) bool {
+				var typeErr *FileTypeError
+				return errors.As(err, &typeErr) && typeErr.ContentType == "text/plain; charset=utf-8"
+			},
+		},
+		{
+			name: "slice type",
+			parts: []testPart{
+				{key: "Docs", filename: "a.txt", content: "a"},
+				{key: "Docs", filename: "b.png", content: pngHeader},
+			},
+			check: func(err error) bool {
+				var typeErr *FileTypeError
+				var elemErr *ElemParseError
+				return errors.As(err, &typeErr) && errors.As(err, &elemErr) && elemErr.Index == 1
+			},
+		},
+		{
+			name: "count",
+			parts: []testPart{
+				{key: "Docs", filename: "a.txt", content: "a"},
+				{key: "Docs", filename: "b.txt", content: "b"},
+				{key: "Docs", filename: "c.txt", content: "c"},
+			},
+			check: func(err error) bool {
+				var countErr *FileCountError
+				return errors.As(err, &countErr) && countErr.Max == 2 && countErr.Got == 3
+			},
+		},
+		{
+			name: "single",
+			parts: []testPart{
+				{key: "Single", filename: "a.txt", content: "a"},
+				{key: "Single", filename: "b.txt", content: "b"},
+			},
+			check: func(err error) bool {
+				var countErr *FileCountError
+				var parseErr *FieldParseError
+				return errors.As(err, &countErr) && countErr.Max == 1 && errors.As(err, &parseErr) && parseErr.Field == "Single"
+			},
+		},
+		{
+			name: "accepted",
+			parts: []testPart{
+				{key: "Avatar", filename: "a.png", content: pngHeader},
+				{key: "Docs", filename: "a.txt", content: "a"},
+				{key: "Docs", filename: "b.pdf", content: "%PDF-1.4"},
+				{key: "Name", filename: "name.txt", content: "a file for a text field"},
+			},
+			check: func(err error) bool {
+				return err == nil
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var dst Upload
+		if err := NewDecoder().DecodeMultipart(readMultipart(t, test.parts...), &dst); !test.check(err) {
+			t.Errorf("Unexpected error for %s: %v", test.name, err)
+		}
+	}
+}
+
+func TestDecodeRequestFiles(t *testing.T) {
+	body, contentType := newMultipart(t,
+		testPart{key: "Name", content: "name"},
+		testPart{key: "Avatar", filename: "avatar.png", content: pngHeader},
+	)
+
+	r := httptest.NewRequest(http.MethodPost, "/", body)
+	r.Header.Set("Content-Type", contentType)
+
+	var dst struct {
+		Name   string
+		Avatar File `file:"accept=image/png"`
+	}
+
+	if err := NewDecoder().DecodeRequest(r, &dst); err != nil {
+		t.Fatalf("DecodeRequest: %q", err)
+	}
+
+	if dst.Name != "name" || dst.Avatar.Name != "avatar.png" {
+		t.Errorf("Unexpected value. Expected name and avatar.png found %q and %q", dst.Name, dst.Avatar.Name)
+	}
+}
+
+func TestDecodeFileSubKeys(t *testing.T) {
+	var dst struct {
+		Avatar File
+	}
+
+	vals := map[string][]string{"Avatar[ContentType]": {"image/png"}, "Avatar[Size]": {"1"}}
+
+	d := NewDecoder()
+	d.Recurse(ListMapDecodeFunc)
+	if err := d.Decode(vals, &dst); err != nil {
+		t.Fatalf("Decode: %q", err)
+	}
+
+	if dst.Avatar.ContentType != "" || dst.Avatar.Size != 0 {
+		t.Errorf("Unexpected value in Avatar. Expected it to be unset found %+v", dst.Avatar)
+	}
+
+	d.DisallowUnknownFields(true)
+
+	var fieldErr *UnexpectedFieldError
+	if err := d.Decode(vals, &dst); !errors.As(err, &fieldErr) {
+		t.Errorf("Expected UnexpectedFieldError found %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package form
+
+import (
+	"net/url"
+	"reflect"
+	"time"
+)
+
+// GeneratedDecoder is implemented by types with a DecodeForm method generated by
+// cmd/formgen. The Decoder calls DecodeForm instead of decoding with reflection when the
+// options the code was generated for match its own, it is not failing fast or rejecting
+// unknown fields and it has the default MaxSliceIndex.
+type GeneratedDecoder interface {
+	DecodeForm(vals url.Values) error
+	FormOptions() GeneratedOptions
+}
+
+// GeneratedEncoder is implemented by types with an EncodeForm method generated by
+// cmd/formgen. The Encoder calls EncodeForm instead of encoding with reflection when the
+// options the code was generated for match its own and it uses the default float format and
+// location.
+type GeneratedEncoder interface {
+	EncodeForm() (url.Values, error)
+	FormOptions() GeneratedOptions
+}
+
+// GeneratedOptions are the options that code generated by cmd/formgen was generated for.
+type GeneratedOptions struct {
+	// StrictCase is the value passed to (*Decoder).StrictCase.
+	StrictCase bool
+	// Recurse is the style of sub keys: "" for none, RecurseNestedMap, RecurseListMap or
+	// RecurseList.
+	Recurse string
+}
+
+// The sub key styles of the DecodeSubKeyFunc and EncodeSubKeyFunc functions in this package.
+const (
+	RecurseNestedMap = "nestedmap"
+	RecurseListMap   = "listmap"
+	RecurseList      = "list"
+)
+
+// recurseCustom is the style of functions that are not in this package. It never matches
+// the style of generated code.
+const recurseCustom = "custom"
+
+func decodeRecurseStyle(f DecodeSubKeyFunc) string {
+	if f == nil {
+		return ""
+	}
+
+	switch reflect.ValueOf(f).Pointer() {
+	case reflect.ValueOf(NestedMapDecodeFunc).Pointer():
+		return RecurseNestedMap
+
+	case reflect.ValueOf(ListMapDecodeFunc).Pointer():
+		return RecurseListMap
+
+	case reflect.ValueOf(ListDecodeFunc).Pointer():
+		return RecurseList
+	}
+
+	return recurseCustom
+}
+
+func encodeRecurseStyle(f EncodeSubKeyFunc) string {
+	if f == nil {
+		return ""
+	}
+
+	switch reflect.ValueOf(f).Pointer() {
+	case reflect.ValueOf(NestedMapEncodeFunc).Pointer():
+		return RecurseNestedMap
+
+	case reflect.ValueOf(ListMapEncodeFunc).Pointer():
+		return RecurseListMap
+
+	case reflect.ValueOf(ListEncodeFunc).Pointer():
+		return RecurseList
+	}
+
+	return recurseCustom
+}
+
+// generatedDecoder returns the generated decoder of dst if it can be used in place of d.
+func (d *Decoder) generatedDecoder(dst interface{}) (GeneratedDecoder, bool) {
+	gen, ok := dst.(GeneratedDecoder)
+	if !ok || d.reflectOnly || d.failFast || d.disallowUnknownFields || d.maxSliceIndex != DefaultMaxSliceIndex || !isSliceRepeat(d.sliceStyle) {
+		return nil, false
+	}
+
+	opts := gen.FormOptions()
+	if opts.StrictCase != d.strictCase || opts.Recurse != decodeRecurseStyle(d.recurse) {
+		return nil, false
+	}
+
+	return gen, true
+}
+
+// DecodeWithReflection decodes vals into dst with a Decoder that has the options in opts,
+// without calling any generated DecodeForm method. Generated code uses it for forms that it
+// does not decode itself, such as slices decoded from indexed sub keys.
+func DecodeWithReflection(vals url.Values, dst interface{}, opts GeneratedOptions) error {
+	d := NewDecoder()
+	d.StrictCase(opts.StrictCase)
+	d.reflectOnly = true
+
+	switch opts.Recurse {
+	case RecurseNestedMap:
+		d.Recurse(NestedMapDecodeFunc)
+
+	case RecurseListMap:
+		d.Recurse(ListMapDecodeFunc)
+
+	case RecurseList:
+		d.Recurse(ListDecodeFunc)
+	}
+
+	return d.Decode(vals, dst)
+}
+
+// generatedEncoder returns the generated encoder of v if it can be used in place of p.
+func (p *Encoder) generatedEncoder(v interface{}) (GeneratedEncoder, bool) {
+	gen, ok := v.(GeneratedEncoder)
+	if !ok || p.FloatFormat != 0 || p.Location != nil || p.OmitZero || !isSliceRepeat(p.SliceStyle) {
+		return nil, false
+	}
+
+	if gen.FormOptions().Recurse != encodeRecurseStyle(p.Recurse) {
+		return nil, false
+	}
+
+	return gen, true
+}
+
+// ParseString returns the single value in vals in the same way as the Decoder decodes a
+// string field. It is used by generated code and can be used by FieldParser implementations.
+func ParseString(vals []string) (string, error) {
+	return parseString(vals)
+}
+
+// ParseBool parses vals in the same way as the Decoder decodes a bool field.
+func ParseBool(vals []string) (bool, error) {
+	return parseBool(vals)
+}
+
+// ParseInt parses vals in the same way as the Decoder decodes an int field with bitSize bits.
+// A bitSize of 0 is the size of an int.
+func ParseInt(vals []string, bitSize int) (int64, error) {
+	return parseInt(vals, bitSize)
+}
+
+// ParseUint parses vals in the same way as the Decoder decodes a uint field with bitSize bits.
+// A bitSize of 0 is the size of a uint.
+func ParseUint(vals []string, bitSize int) (uint64, error) {
+	return parseUint(vals, bitSize)
+}
+
+// ParseFloat parses vals in the same way as the Decoder decodes a float field with bitSize
+// bits.
+func ParseFloat(vals []string, bitSize int) (float64, error) {
+	return parseFloat(vals, bitSize)
+}
+
+// ParseDuration parses vals in the same way as the Decoder decodes a time.Duration field.
+func ParseDuration(vals []string) (time.Duration, error) {
+	return parseDuration(vals)
+}
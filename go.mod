@@ -1,3 +1,3 @@
 module github.com/mattpgray/form
 
-go 1.14
+go 1.22
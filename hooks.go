@@ -0,0 +1,92 @@
+package form
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// BeforeDecoder is implemented by structs that need to run before their fields are decoded,
+// such as to reset state.
+type BeforeDecoder interface {
+	BeforeDecode() error
+}
+
+// AfterDecoder is implemented by structs that need to run after their fields are decoded,
+// such as to normalise values or derive fields from other fields. It is only called if every
+// field of the struct was decoded without an error.
+type AfterDecoder interface {
+	AfterDecode() error
+}
+
+// Validator is implemented by structs that check their values once they are decoded. It is
+// called after AfterDecode.
+type Validator interface {
+	Validate() error
+}
+
+// setStruct sets the fields of the struct v from the form values and calls its hooks. The
+// hooks are called for the struct passed to Decode and every nested struct, whether or not it
+// is in the form values, with the most nested structs first.
+func (d *Decoder) setStruct(v reflect.Value, vals map[string]*formLayer, plan *structPlan, ref fieldRef) error {
+	hooks := structHooks(v)
+
+	if h, ok := hooks.(BeforeDecoder); ok {
+		if err := h.BeforeDecode(); err != nil {
+			return &HookError{Path: ref.path, Hook: "BeforeDecode", Err: err}
+		}
+	}
+
+	if err := d.setMap(v, vals, plan, ref); err != nil {
+		return err
+	}
+
+	if h, ok := hooks.(AfterDecoder); ok {
+		if err := h.AfterDecode(); err != nil {
+			return &HookError{Path: ref.path, Hook: "AfterDecode", Err: err}
+		}
+	}
+
+	if h, ok := hooks.(Validator); ok {
+		if err := h.Validate(); err != nil {
+			return &HookError{Path: ref.path, Hook: "Validate", Err: err}
+		}
+	}
+
+	return nil
+}
+
+// structHooks returns the value to check for hooks, which is a pointer to v if possible so
+// that methods with pointer receivers are found.
+func structHooks(v reflect.Value) interface{} {
+	if v.CanAddr() {
+		v = v.Addr()
+	}
+
+	if !v.CanInterface() {
+		return nil
+	}
+
+	return v.Interface()
+}
+
+// HookError is returned when a BeforeDecode, AfterDecode or Validate hook returns an error.
+type HookError struct {
+	// Path is the full path to the struct, such as Billing.Address or Items[0]. It is empty
+	// for the struct passed to Decode.
+	Path string
+	// Hook is the name of the method that returned the error.
+	Hook string
+	Err  error
+}
+
+func (e *HookError) Error() string {
+	if e.Path == "" {
+		return buildErrorMessage("Parse", fmt.Sprintf("%s: %s", e.Hook, e.Err))
+	}
+
+	return buildErrorMessage("Parse", fmt.Sprintf("%s of %q: %s", e.Hook, e.Path, e.Err))
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,92 @@
+// Package gentest holds types with form methods generated by cmd/formgen. The tests check
+// that the generated methods behave the same as the reflection based Decoder and Encoder.
+package gentest
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"time"
+)
+
+//go:generate go run ../../cmd/formgen -type Order -recurse listmap -strictcase=false
+//go:generate go run ../../cmd/formgen -type Search
+
+type Address struct {
+	Street  string `form:"street"`
+	Zip     string `form:"zip,required"`
+	Country string `form:"country,default=NZ"`
+}
+
+func (a *Address) AfterDecode() error {
+	a.Zip = strings.TrimSpace(a.Zip)
+	return nil
+}
+
+func (a *Address) Validate() error {
+	if len(a.Zip) != 4 {
+		return errors.New("invalid zip")
+	}
+
+	return nil
+}
+
+// Colour is decoded and encoded with FieldParser and FieldEncoder.
+type Colour string
+
+func (c *Colour) ParseField(key string, vals []string) error {
+	if len(vals) != 1 || vals[0] == "" {
+		return errors.New("invalid colour")
+	}
+
+	*c = Colour(strings.ToUpper(vals[0]))
+	return nil
+}
+
+func (c Colour) EncodeField() ([]string, error) {
+	return []string{strings.ToLower(string(c))}, nil
+}
+
+// Cursor is the page token of a search. IsZero reports the token of the first page as zero
+// so that omitempty leaves it out of links.
+type Cursor string
+
+func (c Cursor) IsZero() bool {
+	return c == "" || c == "first"
+}
+
+type Meta struct {
+	Source string `form:"source"`
+}
+
+type Order struct {
+	Name    string        `form:"name,required"`
+	Page    int           `form:"page,default=1"`
+	Price   float32       `form:"price,omitempty"`
+	Count   *uint16       `form:"count"`
+	Tags    []string      `form:"tags"`
+	IDs     []int64       `form:"ids,omitempty"`
+	Agree   bool          `form:"agree"`
+	Timeout time.Duration `form:"timeout"`
+	IP      net.IP        `form:"ip"`
+	Colours []Colour      `form:"colours"`
+	Billing Address       `form:"billing"`
+	Secret  string        `form:"-"`
+	Meta
+}
+
+func (o *Order) BeforeDecode() error {
+	if o.Secret == "reject" {
+		return errors.New("rejected")
+	}
+
+	return nil
+}
+
+type Search struct {
+	Query  string  `form:"q"`
+	Limit  uint8   `form:"limit,omitempty"`
+	Score  float64 `form:"score"`
+	Colour Colour  `form:"colour"`
+	After  Cursor  `form:"after,omitempty"`
+}
@@ -0,0 +1,210 @@
+package gentest
+
+import (
+	"net"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/mattpgray/form"
+)
+
+func TestDecodeForm(t *testing.T) {
+	tests := []struct {
+		name string
+		vals url.Values
+		dst  Order
+	}{
+		{
+			name: "valid",
+			vals: url.Values{
+				"Name":            []string{"Jo"},
+				"page":            []string{"3"},
+				"price":           []string{"9.5"},
+				"count":           []string{"7"},
+				"tags":            []string{"a", "b"},
+				"ids":             []string{"1", "2"},
+				"agree":           []string{"true"},
+				"timeout":         []string{"1m30s"},
+				"ip":              []string{"10.0.0.1"},
+				"colours":         []string{"red", "blue"},
+				"billing[street]": []string{"1 Main St"},
+				"Billing[Zip]":    []string{" 1010 "},
+				"source":          []string{"web"},
+				"unknown":         []string{"x"},
+				"name[x]":         []string{"x"},
+			},
+		},
+		{
+			name: "defaults",
+			vals: url.Values{"name": []string{"Jo"}, "billing[zip]": []string{"1010"}},
+		},
+		{
+			name: "missing",
+			vals: url.Values{},
+		},
+		{
+			name: "missing struct",
+			vals: url.Values{"name": []string{"Jo"}, "page": []string{"x"}},
+		},
+		{
+			name: "parse errors",
+			vals: url.Values{
+				"name":         []string{"a", "b"},
+				"page":         []string{"x"},
+				"price":        []string{"1e100"},
+				"count":        []string{"-1"},
+				"ids":          []string{"1", "x"},
+				"agree":        []string{"yes"},
+				"timeout":      []string{"soon"},
+				"ip":           []string{"nope"},
+				"colours":      []string{"red", ""},
+				"billing":      []string{"x"},
+				"billing[zip]": []string{"10"},
+			},
+		},
+		{
+			name: "hook error",
+			vals: url.Values{"name": []string{"Jo"}, "billing[zip]": []string{"12345"}},
+		},
+		{
+			name: "before decode error",
+			vals: url.Values{"name": []string{"Jo"}},
+			dst:  Order{Secret: "reject"},
+		},
+		{
+			name: "indexed sub keys",
+			vals: url.Values{"name": []string{"Jo"}, "tags[1]": []string{"b"}, "tags[0]": []string{"a"}, "billing[zip]": []string{"1010"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			want := test.dst
+			wantErr := form.DecodeWithReflection(test.vals, &want, want.FormOptions())
+
+			got := test.dst
+			err := got.DecodeForm(test.vals)
+
+			if !reflect.DeepEqual(err, wantErr) {
+				t.Errorf("Unexpected error.\nExpected %#v\nfound    %#v", wantErr, err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Unexpected value.\nExpected %+v\nfound    %+v", want, got)
+			}
+		})
+	}
+}
+
+func TestDecodeFormStrictCase(t *testing.T) {
+	vals := url.Values{
+		"q":      []string{"shoes"},
+		"Limit":  []string{"10"},
+		"limit":  []string{"300"},
+		"score":  []string{"0.5"},
+		"colour": []string{"red"},
+	}
+
+	var want Search
+	wantErr := form.DecodeWithReflection(vals, &want, want.FormOptions())
+
+	var got Search
+	err := form.NewDecoder().Decode(vals, &got)
+
+	if !reflect.DeepEqual(err, wantErr) {
+		t.Errorf("Unexpected error.\nExpected %#v\nfound    %#v", wantErr, err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected value.\nExpected %+v\nfound    %+v", want, got)
+	}
+}
+
+func TestEncodeForm(t *testing.T) {
+	count := uint16(7)
+	tests := []Order{
+		{},
+		{
+			Name:    "Jo",
+			Page:    2,
+			Price:   9.5,
+			Count:   &count,
+			Tags:    []string{"a", "b"},
+			IDs:     []int64{1, 2},
+			Agree:   true,
+			IP:      net.IPv4(10, 0, 0, 1),
+			Colours: []Colour{"RED"},
+			Billing: Address{Street: "1 Main St", Zip: "1010"},
+			Secret:  "s",
+			Meta:    Meta{Source: "web"},
+		},
+	}
+
+	// A float format stops the Encoder from using the generated method.
+	reflectEncoder := &form.Encoder{Recurse: form.ListMapEncodeFunc, FloatFormat: 'g', FloatPrecision: -1}
+
+	for i, test := range tests {
+		want, wantErr := reflectEncoder.Encode(test)
+		got, err := test.EncodeForm()
+
+		if !reflect.DeepEqual(err, wantErr) {
+			t.Errorf("Unexpected error %d. Expected %v found %v", i, wantErr, err)
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Unexpected values %d.\nExpected %v\nfound    %v", i, want, got)
+		}
+
+		viaEncoder, err := (&form.Encoder{Recurse: form.ListMapEncodeFunc}).Encode(&test)
+		if err != nil || !reflect.DeepEqual(viaEncoder, got) {
+			t.Errorf("Unexpected values from Encoder %d. Expected %v found %v: %v", i, got, viaEncoder, err)
+		}
+	}
+}
+
+func TestEncodeFormIsZero(t *testing.T) {
+	reflectEncoder := &form.Encoder{FloatFormat: 'g', FloatPrecision: -1}
+
+	for _, after := range []Cursor{"", "first", "next"} {
+		test := Search{Query: "shoes", Colour: "RED", After: after}
+
+		want, wantErr := reflectEncoder.Encode(test)
+		got, err := test.EncodeForm()
+
+		if !reflect.DeepEqual(err, wantErr) {
+			t.Errorf("Unexpected error for %q. Expected %v found %v", after, wantErr, err)
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Unexpected values for %q.\nExpected %v\nfound    %v", after, want, got)
+		}
+	}
+}
+
+func TestFormSliceStyle(t *testing.T) {
+	test := Order{Name: "Jo", Tags: []string{"a", "b"}, Billing: Address{Zip: "1010"}}
+
+	vals, err := (&form.Encoder{Recurse: form.ListMapEncodeFunc, SliceStyle: form.SliceBrackets}).Encode(test)
+	if err != nil {
+		t.Fatalf("Encode: %q", err)
+	}
+
+	if got := vals["tags[]"]; !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Unexpected value in tags[]. Expected [a b] found %v", got)
+	}
+
+	d := form.NewDecoder()
+	d.StrictCase(false)
+	d.Recurse(form.ListMapDecodeFunc)
+	d.SliceStyle(form.SliceBrackets)
+
+	var got Order
+	if err := d.Decode(vals, &got); err != nil {
+		t.Fatalf("Decode: %q", err)
+	}
+
+	if !reflect.DeepEqual(got.Tags, test.Tags) {
+		t.Errorf("Unexpected value in Tags. Expected %v found %v", test.Tags, got.Tags)
+	}
+}
@@ -0,0 +1,544 @@
+// Code generated by formgen. DO NOT EDIT.
+
+package gentest
+
+import (
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattpgray/form"
+)
+
+// FormOptions returns the options that the form methods of Order were generated for.
+func (Order) FormOptions() form.GeneratedOptions {
+	return form.GeneratedOptions{StrictCase: false, Recurse: "listmap"}
+}
+
+// DecodeForm decodes vals into x in the same way as a form.Decoder with the options from
+// FormOptions.
+func (x *Order) DecodeForm(vals url.Values) error {
+	var (
+		vals0  []string
+		key0   string
+		set0   bool
+		sub0   bool
+		vals1  []string
+		key1   string
+		set1   bool
+		sub1   bool
+		vals2  []string
+		key2   string
+		set2   bool
+		vals3  []string
+		key3   string
+		set3   bool
+		vals4  []string
+		key4   string
+		set4   bool
+		sub4   bool
+		vals5  []string
+		key5   string
+		set5   bool
+		sub5   bool
+		vals6  []string
+		key6   string
+		set6   bool
+		vals7  []string
+		key7   string
+		set7   bool
+		vals8  []string
+		key8   string
+		set8   bool
+		sub8   bool
+		vals9  []string
+		key9   string
+		set9   bool
+		sub9   bool
+		vals10 []string
+		key10  string
+		set10  bool
+		sub10  bool
+		vals11 []string
+		key11  string
+		set11  bool
+		vals12 []string
+		key12  string
+		set12  bool
+		sub12  bool
+		vals13 []string
+		key13  string
+		set13  bool
+		sub13  bool
+		vals14 []string
+		key14  string
+		set14  bool
+	)
+	for key, kv := range vals {
+		k := strings.ToLower(key)
+		parts := form.ListMapDecodeFunc(k)
+		switch parts[0] {
+		case "agree":
+			if len(parts) > 1 {
+				break
+			}
+			if !set6 {
+				key6 = key
+			}
+			set6 = true
+			vals6 = append(vals6, kv...)
+		case "billing":
+			if len(parts) > 1 {
+				sub10 = true
+				switch parts[1] {
+				case "country":
+					if len(parts) > 2 {
+						sub13 = true
+						break
+					}
+					if !set13 {
+						key13 = key
+					}
+					set13 = true
+					vals13 = append(vals13, kv...)
+				case "street":
+					if len(parts) > 2 {
+						break
+					}
+					if !set11 {
+						key11 = key
+					}
+					set11 = true
+					vals11 = append(vals11, kv...)
+				case "zip":
+					if len(parts) > 2 {
+						sub12 = true
+						break
+					}
+					if !set12 {
+						key12 = key
+					}
+					set12 = true
+					vals12 = append(vals12, kv...)
+				}
+				break
+			}
+			if !set10 {
+				key10 = key
+			}
+			set10 = true
+			vals10 = append(vals10, kv...)
+		case "colours":
+			if len(parts) > 1 {
+				sub9 = true
+				break
+			}
+			if !set9 {
+				key9 = key
+			}
+			set9 = true
+			vals9 = append(vals9, kv...)
+		case "count":
+			if len(parts) > 1 {
+				break
+			}
+			if !set3 {
+				key3 = key
+			}
+			set3 = true
+			vals3 = append(vals3, kv...)
+		case "ids":
+			if len(parts) > 1 {
+				sub5 = true
+				break
+			}
+			if !set5 {
+				key5 = key
+			}
+			set5 = true
+			vals5 = append(vals5, kv...)
+		case "ip":
+			if len(parts) > 1 {
+				sub8 = true
+				break
+			}
+			if !set8 {
+				key8 = key
+			}
+			set8 = true
+			vals8 = append(vals8, kv...)
+		case "name":
+			if len(parts) > 1 {
+				sub0 = true
+				break
+			}
+			if !set0 {
+				key0 = key
+			}
+			set0 = true
+			vals0 = append(vals0, kv...)
+		case "page":
+			if len(parts) > 1 {
+				sub1 = true
+				break
+			}
+			if !set1 {
+				key1 = key
+			}
+			set1 = true
+			vals1 = append(vals1, kv...)
+		case "price":
+			if len(parts) > 1 {
+				break
+			}
+			if !set2 {
+				key2 = key
+			}
+			set2 = true
+			vals2 = append(vals2, kv...)
+		case "source":
+			if len(parts) > 1 {
+				break
+			}
+			if !set14 {
+				key14 = key
+			}
+			set14 = true
+			vals14 = append(vals14, kv...)
+		case "tags":
+			if len(parts) > 1 {
+				sub4 = true
+				break
+			}
+			if !set4 {
+				key4 = key
+			}
+			set4 = true
+			vals4 = append(vals4, kv...)
+		case "timeout":
+			if len(parts) > 1 {
+				break
+			}
+			if !set7 {
+				key7 = key
+			}
+			set7 = true
+			vals7 = append(vals7, kv...)
+		}
+	}
+	if sub4 {
+		return form.DecodeWithReflection(vals, x, x.FormOptions())
+	}
+	if sub5 {
+		return form.DecodeWithReflection(vals, x, x.FormOptions())
+	}
+	if sub8 {
+		return form.DecodeWithReflection(vals, x, x.FormOptions())
+	}
+	if sub9 {
+		return form.DecodeWithReflection(vals, x, x.FormOptions())
+	}
+	if err := x.BeforeDecode(); err != nil {
+		return &form.HookError{Path: "", Hook: "BeforeDecode", Err: err}
+	}
+	var errs form.DecodeErrors
+	decode10 := func() error {
+		var errs form.DecodeErrors
+		if set13 {
+			v, err := form.ParseString(vals13)
+			if err == nil {
+				x.Billing.Country = string(v)
+			}
+			if err != nil {
+				errs = errs.Add(&form.FieldParseError{Field: "country", Path: "Billing.Country", Key: key13, Vals: vals13, Err: err})
+			}
+		}
+		if set11 {
+			v, err := form.ParseString(vals11)
+			if err == nil {
+				x.Billing.Street = string(v)
+			}
+			if err != nil {
+				errs = errs.Add(&form.FieldParseError{Field: "street", Path: "Billing.Street", Key: key11, Vals: vals11, Err: err})
+			}
+		}
+		if set12 {
+			v, err := form.ParseString(vals12)
+			if err == nil {
+				x.Billing.Zip = string(v)
+			}
+			if err != nil {
+				errs = errs.Add(&form.FieldParseError{Field: "zip", Path: "Billing.Zip", Key: key12, Vals: vals12, Err: err})
+			}
+		}
+		if !(set13 || sub13) {
+			defaultVals := []string{"NZ"}
+			v, err := form.ParseString(defaultVals)
+			if err == nil {
+				x.Billing.Country = string(v)
+			}
+			if err != nil {
+				errs = errs.Add(&form.FieldParseError{Field: "country", Path: "Billing.Country", Vals: defaultVals, Err: err})
+			}
+		}
+		if !(set12 || sub12) {
+			errs = errs.Add(&form.RequiredFieldError{Field: "zip", Path: "Billing.Zip"})
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+		if err := x.Billing.AfterDecode(); err != nil {
+			return &form.HookError{Path: "Billing", Hook: "AfterDecode", Err: err}
+		}
+		if err := x.Billing.Validate(); err != nil {
+			return &form.HookError{Path: "Billing", Hook: "Validate", Err: err}
+		}
+		return nil
+	}
+	if set6 {
+		v, err := form.ParseBool(vals6)
+		if err == nil {
+			x.Agree = bool(v)
+		}
+		if err != nil {
+			errs = errs.Add(&form.FieldParseError{Field: "agree", Path: "Agree", Key: key6, Vals: vals6, Err: err})
+		}
+	}
+	if set10 {
+		errs = errs.Add(&form.FieldTypeError{Field: "billing", Path: "Billing", Key: key10, Vals: vals10, Type: reflect.TypeOf(x.Billing)})
+	}
+	if sub10 {
+		if err := decode10(); err != nil {
+			errs = errs.Add(err)
+		}
+	}
+	if set9 {
+		var err error
+		set := make([]Colour, len(vals9))
+		for i := range vals9 {
+			elemErr := set[i].ParseField("colours", vals9[i:i+1])
+			if elemErr != nil {
+				err = &form.ElemParseError{Index: i, Err: elemErr}
+				break
+			}
+		}
+		if err == nil {
+			x.Colours = set
+		}
+		if err != nil {
+			errs = errs.Add(&form.FieldParseError{Field: "colours", Path: "Colours", Key: key9, Vals: vals9, Err: err})
+		}
+	}
+	if set3 {
+		if x.Count == nil {
+			x.Count = new(uint16)
+		}
+		v, err := form.ParseUint(vals3, 16)
+		if err == nil {
+			*x.Count = uint16(v)
+		}
+		if err != nil {
+			errs = errs.Add(&form.FieldParseError{Field: "count", Path: "Count", Key: key3, Vals: vals3, Err: err})
+		}
+	}
+	if set5 {
+		var err error
+		set := make([]int64, len(vals5))
+		for i := range vals5 {
+			v, elemErr := form.ParseInt(vals5[i:i+1], 64)
+			if elemErr == nil {
+				set[i] = int64(v)
+			}
+			if elemErr != nil {
+				err = &form.ElemParseError{Index: i, Err: elemErr}
+				break
+			}
+		}
+		if err == nil {
+			x.IDs = set
+		}
+		if err != nil {
+			errs = errs.Add(&form.FieldParseError{Field: "ids", Path: "IDs", Key: key5, Vals: vals5, Err: err})
+		}
+	}
+	if set8 {
+		s, err := form.ParseString(vals8)
+		if err == nil {
+			err = x.IP.UnmarshalText([]byte(s))
+		}
+		if err != nil {
+			errs = errs.Add(&form.FieldParseError{Field: "ip", Path: "IP", Key: key8, Vals: vals8, Err: err})
+		}
+	}
+	if set0 {
+		v, err := form.ParseString(vals0)
+		if err == nil {
+			x.Name = string(v)
+		}
+		if err != nil {
+			errs = errs.Add(&form.FieldParseError{Field: "name", Path: "Name", Key: key0, Vals: vals0, Err: err})
+		}
+	}
+	if set1 {
+		v, err := form.ParseInt(vals1, 0)
+		if err == nil {
+			x.Page = int(v)
+		}
+		if err != nil {
+			errs = errs.Add(&form.FieldParseError{Field: "page", Path: "Page", Key: key1, Vals: vals1, Err: err})
+		}
+	}
+	if set2 {
+		v, err := form.ParseFloat(vals2, 32)
+		if err == nil {
+			x.Price = float32(v)
+		}
+		if err != nil {
+			errs = errs.Add(&form.FieldParseError{Field: "price", Path: "Price", Key: key2, Vals: vals2, Err: err})
+		}
+	}
+	if set14 {
+		v, err := form.ParseString(vals14)
+		if err == nil {
+			x.Meta.Source = string(v)
+		}
+		if err != nil {
+			errs = errs.Add(&form.FieldParseError{Field: "source", Path: "Source", Key: key14, Vals: vals14, Err: err})
+		}
+	}
+	if set4 {
+		var err error
+		set := make([]string, len(vals4))
+		for i := range vals4 {
+			v, elemErr := form.ParseString(vals4[i : i+1])
+			if elemErr == nil {
+				set[i] = string(v)
+			}
+			if elemErr != nil {
+				err = &form.ElemParseError{Index: i, Err: elemErr}
+				break
+			}
+		}
+		if err == nil {
+			x.Tags = set
+		}
+		if err != nil {
+			errs = errs.Add(&form.FieldParseError{Field: "tags", Path: "Tags", Key: key4, Vals: vals4, Err: err})
+		}
+	}
+	if set7 {
+		v, err := form.ParseDuration(vals7)
+		if err == nil {
+			x.Timeout = time.Duration(v)
+		}
+		if err != nil {
+			errs = errs.Add(&form.FieldParseError{Field: "timeout", Path: "Timeout", Key: key7, Vals: vals7, Err: err})
+		}
+	}
+	if !(set10 || sub10) {
+		if err := decode10(); err != nil {
+			errs = errs.Add(err)
+		}
+	}
+	if !(set0 || sub0) {
+		errs = errs.Add(&form.RequiredFieldError{Field: "name", Path: "Name"})
+	}
+	if !(set1 || sub1) {
+		defaultVals := []string{"1"}
+		v, err := form.ParseInt(defaultVals, 0)
+		if err == nil {
+			x.Page = int(v)
+		}
+		if err != nil {
+			errs = errs.Add(&form.FieldParseError{Field: "page", Path: "Page", Vals: defaultVals, Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// EncodeForm encodes x in the same way as a form.Encoder with the options from FormOptions
+// and the default float format and location.
+func (x Order) EncodeForm() (url.Values, error) {
+	vals := url.Values{}
+	{
+		ev := []string{string(x.Name)}
+		vals["name"] = ev
+	}
+	{
+		ev := []string{strconv.FormatInt(int64(x.Page), 10)}
+		vals["page"] = ev
+	}
+	if x.Price != 0 {
+		ev := []string{strconv.FormatFloat(float64(x.Price), 'g', -1, 32)}
+		vals["price"] = ev
+	}
+	if x.Count != nil {
+		ev := []string{strconv.FormatUint(uint64(*x.Count), 10)}
+		vals["count"] = ev
+	}
+	{
+		ev := make([]string, 0, len(x.Tags))
+		for i := range x.Tags {
+			elemVals := []string{string(x.Tags[i])}
+			ev = append(ev, elemVals...)
+		}
+		vals["tags"] = ev
+	}
+	if len(x.IDs) != 0 {
+		ev := make([]string, 0, len(x.IDs))
+		for i := range x.IDs {
+			elemVals := []string{strconv.FormatInt(int64(x.IDs[i]), 10)}
+			ev = append(ev, elemVals...)
+		}
+		vals["ids"] = ev
+	}
+	{
+		ev := []string{strconv.FormatBool(bool(x.Agree))}
+		vals["agree"] = ev
+	}
+	{
+		ev := []string{x.Timeout.String()}
+		vals["timeout"] = ev
+	}
+	{
+		b, err := x.IP.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		ev := []string{string(b)}
+		vals["ip"] = ev
+	}
+	{
+		ev := make([]string, 0, len(x.Colours))
+		for i := range x.Colours {
+			elemVals, err := x.Colours[i].EncodeField()
+			if err != nil {
+				return nil, err
+			}
+			ev = append(ev, elemVals...)
+		}
+		vals["colours"] = ev
+	}
+	{
+		ev := []string{string(x.Billing.Street)}
+		vals["billing[street]"] = ev
+	}
+	{
+		ev := []string{string(x.Billing.Zip)}
+		vals["billing[zip]"] = ev
+	}
+	{
+		ev := []string{string(x.Billing.Country)}
+		vals["billing[country]"] = ev
+	}
+	{
+		ev := []string{string(x.Meta.Source)}
+		vals["source"] = ev
+	}
+	return vals, nil
+}
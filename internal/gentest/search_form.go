@@ -0,0 +1,149 @@
+// Code generated by formgen. DO NOT EDIT.
+
+package gentest
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/mattpgray/form"
+)
+
+// FormOptions returns the options that the form methods of Search were generated for.
+func (Search) FormOptions() form.GeneratedOptions {
+	return form.GeneratedOptions{StrictCase: true, Recurse: ""}
+}
+
+// DecodeForm decodes vals into x in the same way as a form.Decoder with the options from
+// FormOptions.
+func (x *Search) DecodeForm(vals url.Values) error {
+	var (
+		vals0 []string
+		key0  string
+		set0  bool
+		vals1 []string
+		key1  string
+		set1  bool
+		vals2 []string
+		key2  string
+		set2  bool
+		vals3 []string
+		key3  string
+		set3  bool
+		vals4 []string
+		key4  string
+		set4  bool
+	)
+	for key, kv := range vals {
+		k := key
+		switch k {
+		case "after":
+			if !set4 {
+				key4 = key
+			}
+			set4 = true
+			vals4 = append(vals4, kv...)
+		case "colour":
+			if !set3 {
+				key3 = key
+			}
+			set3 = true
+			vals3 = append(vals3, kv...)
+		case "limit":
+			if !set1 {
+				key1 = key
+			}
+			set1 = true
+			vals1 = append(vals1, kv...)
+		case "q":
+			if !set0 {
+				key0 = key
+			}
+			set0 = true
+			vals0 = append(vals0, kv...)
+		case "score":
+			if !set2 {
+				key2 = key
+			}
+			set2 = true
+			vals2 = append(vals2, kv...)
+		}
+	}
+	var errs form.DecodeErrors
+	if set4 {
+		v, err := form.ParseString(vals4)
+		if err == nil {
+			x.After = Cursor(v)
+		}
+		if err != nil {
+			errs = errs.Add(&form.FieldParseError{Field: "after", Path: "After", Key: key4, Vals: vals4, Err: err})
+		}
+	}
+	if set3 {
+		err := x.Colour.ParseField("colour", vals3)
+		if err != nil {
+			errs = errs.Add(&form.FieldParseError{Field: "colour", Path: "Colour", Key: key3, Vals: vals3, Err: err})
+		}
+	}
+	if set1 {
+		v, err := form.ParseUint(vals1, 8)
+		if err == nil {
+			x.Limit = uint8(v)
+		}
+		if err != nil {
+			errs = errs.Add(&form.FieldParseError{Field: "limit", Path: "Limit", Key: key1, Vals: vals1, Err: err})
+		}
+	}
+	if set0 {
+		v, err := form.ParseString(vals0)
+		if err == nil {
+			x.Query = string(v)
+		}
+		if err != nil {
+			errs = errs.Add(&form.FieldParseError{Field: "q", Path: "Query", Key: key0, Vals: vals0, Err: err})
+		}
+	}
+	if set2 {
+		v, err := form.ParseFloat(vals2, 64)
+		if err == nil {
+			x.Score = float64(v)
+		}
+		if err != nil {
+			errs = errs.Add(&form.FieldParseError{Field: "score", Path: "Score", Key: key2, Vals: vals2, Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// EncodeForm encodes x in the same way as a form.Encoder with the options from FormOptions
+// and the default float format and location.
+func (x Search) EncodeForm() (url.Values, error) {
+	vals := url.Values{}
+	{
+		ev := []string{string(x.Query)}
+		vals["q"] = ev
+	}
+	if x.Limit != 0 {
+		ev := []string{strconv.FormatUint(uint64(x.Limit), 10)}
+		vals["limit"] = ev
+	}
+	{
+		ev := []string{strconv.FormatFloat(float64(x.Score), 'g', -1, 64)}
+		vals["score"] = ev
+	}
+	{
+		ev, err := x.Colour.EncodeField()
+		if err != nil {
+			return nil, err
+		}
+		vals["colour"] = ev
+	}
+	if !x.After.IsZero() {
+		ev := []string{string(x.After)}
+		vals["after"] = ev
+	}
+	return vals, nil
+}
@@ -0,0 +1,112 @@
+package form
+
+import (
+	"fmt"
+	"mime/multipart"
+	"net/url"
+)
+
+// The limits below are not set by default. They protect decoding from forms that are built to
+// use up memory or CPU, such as on public endpoints. DecodeRequest also limits the size of the
+// body with MaxBodySize.
+
+// MaxKeys sets the most form keys that can be decoded. Files of a multipart form count
+// towards the limit. A limit of 0 or less removes it.
+func (d *Decoder) MaxKeys(n int) {
+	d.maxKeys = n
+}
+
+// MaxDepth sets the most parts that a key can be split into by Recurse, so that
+// items[0][name] has a depth of 3. A limit of 0 or less removes it.
+func (d *Decoder) MaxDepth(n int) {
+	d.maxDepth = n
+}
+
+// MaxValuesPerKey sets the most values, or files, that can be submitted with a single key. A
+// limit of 0 or less removes it.
+func (d *Decoder) MaxValuesPerKey(n int) {
+	d.maxValuesPerKey = n
+}
+
+// MaxBytes sets the most bytes that the keys and values of a form can have in total. Files
+// are not counted. A limit of 0 or less removes it.
+func (d *Decoder) MaxBytes(n int) {
+	d.maxBytes = n
+}
+
+// checkLimits returns a LimitError if the form values exceed the limits of the Decoder.
+func (d *Decoder) checkLimits(vals url.Values, files map[string][]*multipart.FileHeader) error {
+	if d.maxKeys > 0 {
+		n := len(vals)
+		for k := range files {
+			if _, ok := vals[k]; !ok {
+				n++
+			}
+		}
+
+		if n > d.maxKeys {
+			return &LimitError{Limit: "MaxKeys", Max: d.maxKeys}
+		}
+	}
+
+	if d.maxValuesPerKey > 0 {
+		for k, v := range vals {
+			if len(v)+len(files[k]) > d.maxValuesPerKey {
+				return &LimitError{Limit: "MaxValuesPerKey", Max: d.maxValuesPerKey, Key: k}
+			}
+		}
+
+		for k, v := range files {
+			if len(v) > d.maxValuesPerKey {
+				return &LimitError{Limit: "MaxValuesPerKey", Max: d.maxValuesPerKey, Key: k}
+			}
+		}
+	}
+
+	if d.maxBytes > 0 {
+		n := 0
+		for k, v := range vals {
+			n += len(k)
+			for _, s := range v {
+				n += len(s)
+			}
+
+			if n > d.maxBytes {
+				return &LimitError{Limit: "MaxBytes", Max: d.maxBytes}
+			}
+		}
+	}
+
+	if d.maxDepth > 0 && d.recurse != nil {
+		for k := range vals {
+			if len(d.recurse(k)) > d.maxDepth {
+				return &LimitError{Limit: "MaxDepth", Max: d.maxDepth, Key: k}
+			}
+		}
+
+		for k := range files {
+			if len(d.recurse(k)) > d.maxDepth {
+				return &LimitError{Limit: "MaxDepth", Max: d.maxDepth, Key: k}
+			}
+		}
+	}
+
+	return nil
+}
+
+// LimitError is returned when the form values exceed one of the limits of the Decoder.
+type LimitError struct {
+	// Limit is the name of the Decoder method that sets the limit, such as MaxKeys.
+	Limit string
+	Max   int
+	// Key is the form key that exceeded the limit, if the limit is for a single key.
+	Key string
+}
+
+func (e *LimitError) Error() string {
+	if e.Key == "" {
+		return buildErrorMessage("Parse", fmt.Sprintf("form exceeds %s of %d", e.Limit, e.Max))
+	}
+
+	return buildErrorMessage("Parse", fmt.Sprintf("key %q exceeds %s of %d", e.Key, e.Limit, e.Max))
+}
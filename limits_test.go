@@ -0,0 +1,92 @@
+package form
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDecodeLimits(t *testing.T) {
+	type Item struct {
+		SKU string
+	}
+
+	type limitForm struct {
+		Name  string
+		Tags  []string
+		Items []Item
+	}
+
+	tests := []struct {
+		name  string
+		setup func(d *Decoder)
+		vals  url.Values
+		limit string
+		key   string
+	}{
+		{
+			name:  "keys",
+			setup: func(d *Decoder) { d.MaxKeys(2) },
+			vals:  url.Values{"Name": {"n"}, "Tags": {"a"}, "Other": {"o"}},
+			limit: "MaxKeys",
+		},
+		{
+			name:  "values per key",
+			setup: func(d *Decoder) { d.MaxValuesPerKey(2) },
+			vals:  url.Values{"Name": {"n"}, "Tags": {"a", "b", "c"}},
+			limit: "MaxValuesPerKey",
+			key:   "Tags",
+		},
+		{
+			name:  "bytes",
+			setup: func(d *Decoder) { d.MaxBytes(10) },
+			vals:  url.Values{"Name": {strings.Repeat("n", 7)}},
+			limit: "MaxBytes",
+		},
+		{
+			name:  "depth",
+			setup: func(d *Decoder) { d.MaxDepth(3) },
+			vals:  url.Values{"Items[0][SKU][x]": {"a"}},
+			limit: "MaxDepth",
+			key:   "Items[0][SKU][x]",
+		},
+		{
+			name:  "slice index",
+			setup: func(d *Decoder) { d.MaxSliceIndex(5) },
+			vals:  url.Values{"Items[6][SKU]": {"a"}},
+			limit: "MaxSliceIndex",
+			key:   "Items[6][SKU]",
+		},
+	}
+
+	for _, test := range tests {
+		d := NewDecoder()
+		d.Recurse(ListMapDecodeFunc)
+		test.setup(d)
+
+		var dst limitForm
+		var limitErr *LimitError
+		if err := d.Decode(test.vals, &dst); !errors.As(err, &limitErr) {
+			t.Errorf("Expected LimitError for %s found %v", test.name, err)
+			continue
+		}
+
+		if limitErr.Limit != test.limit || limitErr.Key != test.key {
+			t.Errorf("Unexpected limit for %s. Expected %s for %q found %s for %q", test.name, test.limit, test.key, limitErr.Limit, limitErr.Key)
+		}
+	}
+
+	d := NewDecoder()
+	d.Recurse(ListMapDecodeFunc)
+	d.MaxKeys(3)
+	d.MaxValuesPerKey(2)
+	d.MaxBytes(30)
+	d.MaxDepth(3)
+
+	vals := url.Values{"Name": {"n"}, "Tags": {"a", "b"}, "Items[0][SKU]": {"s"}}
+	var dst limitForm
+	if err := d.Decode(vals, &dst); err != nil {
+		t.Errorf("Decode within limits: %q", err)
+	}
+}
@@ -0,0 +1,207 @@
+package form
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// multipartFile is a file found while encoding a multipart body.
+type multipartFile struct {
+	key         string
+	name        string
+	contentType string
+	open        func() (io.ReadCloser, error)
+}
+
+var (
+	fileHeaderType = reflect.TypeOf(multipart.FileHeader{})
+	readerType     = reflect.TypeOf((*io.Reader)(nil)).Elem()
+)
+
+// EncodeMultipart writes v to w as a multipart/form-data body and returns the content type of
+// the body, which includes the boundary. Fields are encoded in the same way as Encode, except
+// that File, *multipart.FileHeader and io.Reader fields, and slices of them, are written as
+// file parts after the values. The content of each file is copied to w as it is read rather
+// than being held in memory.
+//
+// The name of a File or *multipart.FileHeader is used as the file name of the part, and the
+// name of an io.Reader is taken from its Name method, such as for an *os.File. Otherwise the
+// field name is used. Files without a content type are sent as application/octet-stream.
+// Files with a nil Open function and nil readers are skipped. Readers are not closed.
+func (p *Encoder) EncodeMultipart(w io.Writer, v interface{}) (contentType string, err error) {
+	var files []multipartFile
+
+	enc := *p
+	enc.files = &files
+
+	vals, err := enc.Encode(v)
+	if err != nil {
+		return "", err
+	}
+
+	keys := make([]string, 0, len(vals))
+	for k := range vals {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	mw := multipart.NewWriter(w)
+	for _, k := range keys {
+		for _, val := range vals[k] {
+			if err := mw.WriteField(k, val); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	for _, f := range files {
+		if err := writeFilePart(mw, f); err != nil {
+			return "", err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+
+	return mw.FormDataContentType(), nil
+}
+
+// addFiles adds the files in v under the joined keys if v holds files. ok is false if v is
+// not a file value. Files can only be encoded by EncodeMultipart.
+func (p *Encoder) addFiles(v reflect.Value, keys []string) (ok bool, err error) {
+	if p.files == nil {
+		// Only check for the file types so that Encode does not flatten them into sub keys.
+		// Checking for readers is left to EncodeMultipart as it is slower.
+		t := v.Type()
+		if t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+
+		if base := baseType(t); base == fileType || base == fileHeaderType {
+			return true, errUnsupportedType
+		}
+
+		return false, nil
+	}
+
+	var files []multipartFile
+	if f, ok := encodeFile(v); ok {
+		files = append(files, f)
+	} else if v.Kind() == reflect.Slice && isEncodeFileType(v.Type().Elem()) {
+		for i := 0; i < v.Len(); i++ {
+			f, _ := encodeFile(v.Index(i))
+			files = append(files, f)
+		}
+	} else {
+		return false, nil
+	}
+
+	key := p.joinKeys(keys)
+	for _, f := range files {
+		if f.open == nil {
+			continue
+		}
+
+		f.key = key
+		if f.name == "" {
+			f.name = keys[len(keys)-1]
+		}
+
+		*p.files = append(*p.files, f)
+	}
+
+	return true, nil
+}
+
+// isEncodeFileType reports whether values of type t are written as file parts.
+func isEncodeFileType(t reflect.Type) bool {
+	base := baseType(t)
+	return base == fileType || base == fileHeaderType || t.Implements(readerType) || reflect.PtrTo(t).Implements(readerType)
+}
+
+// encodeFile returns the file in v. The file has a nil open function if v is nil.
+func encodeFile(v reflect.Value) (multipartFile, bool) {
+	if !isEncodeFileType(v.Type()) {
+		return multipartFile{}, false
+	}
+
+	switch base := baseElem(v); {
+	case !base.IsValid():
+		return multipartFile{}, true
+
+	case base.Type() == fileType:
+		f := base.Interface().(File)
+		return multipartFile{name: f.Name, contentType: f.ContentType, open: f.Open}, true
+
+	case base.Type() == fileHeaderType:
+		fh := base.Interface().(multipart.FileHeader)
+		open := func() (io.ReadCloser, error) {
+			return fh.Open()
+		}
+
+		return multipartFile{name: fh.Filename, contentType: fh.Header.Get("Content-Type"), open: open}, true
+	}
+
+	var r io.Reader
+	switch {
+	case v.Type().Implements(readerType):
+		if (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) && v.IsNil() {
+			return multipartFile{}, true
+		}
+
+		r = v.Interface().(io.Reader)
+
+	case v.CanAddr():
+		r = v.Addr().Interface().(io.Reader)
+
+	default:
+		return multipartFile{}, false
+	}
+
+	var name string
+	if named, ok := r.(interface{ Name() string }); ok {
+		name = filepath.Base(named.Name())
+	}
+
+	open := func() (io.ReadCloser, error) {
+		return io.NopCloser(r), nil
+	}
+
+	return multipartFile{name: name, open: open}, true
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// writeFilePart copies the content of a file into a new part.
+func writeFilePart(mw *multipart.Writer, f multipartFile) error {
+	contentType := f.contentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(f.key), quoteEscaper.Replace(f.name)))
+	h.Set("Content-Type", contentType)
+
+	part, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+
+	r, err := f.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, err = io.Copy(part, r)
+	return err
+}
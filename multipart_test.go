@@ -0,0 +1,176 @@
+package form
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEncodeMultipart(t *testing.T) {
+	type Profile struct {
+		Bio    string
+		Avatar File
+	}
+
+	src := struct {
+		Name    string
+		Tags    []string
+		Avatar  File
+		Readme  io.Reader
+		Buffer  bytes.Buffer
+		Photos  []File
+		Empty   File
+		Profile Profile
+	}{
+		Name: "name",
+		Tags: []string{"a", "b"},
+		Avatar: File{
+			Name:        "avatar.png",
+			ContentType: "image/png",
+			Open: func() (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader(pngHeader)), nil
+			},
+		},
+		Readme: strings.NewReader("readme"),
+		Photos: []File{
+			{Name: "a.txt", Open: func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader("a")), nil }},
+			{Name: "b.txt", Open: func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader("b")), nil }},
+		},
+		Profile: Profile{
+			Bio: "bio",
+			Avatar: File{
+				Name: "profile.txt",
+				Open: func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader("profile")), nil },
+			},
+		},
+	}
+	src.Buffer.WriteString("buffer")
+
+	var body bytes.Buffer
+	contentType, err := (&Encoder{Recurse: ListMapEncodeFunc}).EncodeMultipart(&body, &src)
+	if err != nil {
+		t.Fatalf("EncodeMultipart: %q", err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", &body)
+	r.Header.Set("Content-Type", contentType)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatalf("ParseMultipartForm: %q", err)
+	}
+
+	mf := r.MultipartForm
+	if got := mf.Value["Name"]; len(got) != 1 || got[0] != "name" {
+		t.Errorf("Unexpected value in Name. Expected [name] found %v", got)
+	}
+
+	if got := mf.Value["Tags"]; len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("Unexpected value in Tags. Expected [a b] found %v", got)
+	}
+
+	if got := mf.Value["Profile[Bio]"]; len(got) != 1 || got[0] != "bio" {
+		t.Errorf("Unexpected value in Profile[Bio]. Expected [bio] found %v", got)
+	}
+
+	if _, ok := mf.File["Empty"]; ok {
+		t.Errorf("Unexpected file for Empty")
+	}
+
+	files := []struct {
+		key, filename, contentType, content string
+	}{
+		{"Avatar", "avatar.png", "image/png", pngHeader},
+		{"Readme", "Readme", "application/octet-stream", "readme"},
+		{"Buffer", "Buffer", "application/octet-stream", "buffer"},
+		{"Photos", "a.txt", "application/octet-stream", "a"},
+		{"Profile[Avatar]", "profile.txt", "application/octet-stream", "profile"},
+	}
+
+	for _, want := range files {
+		headers := mf.File[want.key]
+		if len(headers) == 0 {
+			t.Errorf("Missing file for %s", want.key)
+			continue
+		}
+
+		fh := headers[0]
+		if fh.Filename != want.filename || fh.Header.Get("Content-Type") != want.contentType {
+			t.Errorf("Unexpected file for %s. Expected %s %s found %s %s", want.key, want.filename, want.contentType, fh.Filename, fh.Header.Get("Content-Type"))
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			t.Fatalf("Open %s: %q", want.key, err)
+		}
+
+		b, _ := io.ReadAll(f)
+		f.Close()
+
+		if string(b) != want.content {
+			t.Errorf("Unexpected content for %s. Expected %q found %q", want.key, want.content, b)
+		}
+	}
+
+	if got := len(mf.File["Photos"]); got != 2 {
+		t.Errorf("Unexpected number of files for Photos. Expected 2 found %d", got)
+	}
+}
+
+func TestEncodeMultipartRoundTrip(t *testing.T) {
+	type Upload struct {
+		Name    string
+		Avatar  File `file:"accept=image/png"`
+		Headers []*multipart.FileHeader
+	}
+
+	mf := readMultipart(t,
+		testPart{key: "Headers", filename: "a.txt", content: "a"},
+		testPart{key: "Headers", filename: "b.txt", content: "b"},
+	)
+
+	src := Upload{
+		Name: "name",
+		Avatar: File{
+			Name: "avatar.png",
+			Open: func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(pngHeader)), nil },
+		},
+		Headers: mf.File["Headers"],
+	}
+
+	var body bytes.Buffer
+	contentType, err := (&Encoder{}).EncodeMultipart(&body, &src)
+	if err != nil {
+		t.Fatalf("EncodeMultipart: %q", err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", &body)
+	r.Header.Set("Content-Type", contentType)
+
+	var dst Upload
+	if err := NewDecoder().DecodeRequest(r, &dst); err != nil {
+		t.Fatalf("DecodeRequest: %q", err)
+	}
+
+	if dst.Name != "name" || dst.Avatar.Name != "avatar.png" || dst.Avatar.ContentType != "image/png" {
+		t.Errorf("Unexpected value. Expected name, avatar.png and image/png found %q, %q and %q", dst.Name, dst.Avatar.Name, dst.Avatar.ContentType)
+	}
+
+	if len(dst.Headers) != 2 || dst.Headers[0].Filename != "a.txt" || dst.Headers[1].Filename != "b.txt" {
+		t.Errorf("Unexpected value in Headers. Expected [a.txt b.txt] found %v", dst.Headers)
+	}
+}
+
+func TestEncodeFileType(t *testing.T) {
+	src := struct {
+		Avatar File
+	}{}
+
+	var typeErr *FieldTypeError
+	if _, err := (&Encoder{Recurse: ListMapEncodeFunc}).Encode(&src); !errors.As(err, &typeErr) || typeErr.Field != "Avatar" {
+		t.Errorf("Expected FieldTypeError for Avatar found %v", err)
+	}
+}
@@ -1,11 +1,16 @@
 package form
 
 import (
+	"encoding"
+	"errors"
 	"fmt"
+	"mime/multipart"
 	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // FieldParser allows callers to implement custom form parsing for a particular field
@@ -21,35 +26,151 @@ type Decoder struct {
 	// Recurse allows sub structs to be populated. Leave nil if you do not want sub keys to be parsed.
 	// If Recurse is nil and a sub struct/map is found then it is ignored.
 	recurse DecodeSubKeyFunc
+	// allowShortArrays allows fewer values than the length of an array field.
+	allowShortArrays bool
+	// location is used for time values without a time zone.
+	location *time.Location
+	// maxSliceIndex is the largest index allowed in the sub keys of a slice.
+	maxSliceIndex int
+	// sliceStyle is the format of the values of slice and array fields.
+	sliceStyle SliceStyle
+	// failFast stops decoding at the first error.
+	failFast bool
+	// disallowUnknownFields returns an error for keys that do not match a field.
+	disallowUnknownFields bool
+	// allowedFields are keys that are ignored even if unknown fields are disallowed.
+	allowedFields map[string]bool
+	// rules are the custom rules for the validate tag.
+	rules map[string]ValidationRule
+	// reflectOnly stops generated DecodeForm methods being used.
+	reflectOnly bool
+	// requestSource is the parts of a request read by DecodeRequest.
+	requestSource RequestSource
+	// maxBodySize is the largest request body read by DecodeRequest.
+	maxBodySize int64
+	// errorRenderer writes the response when Handler cannot decode a request.
+	errorRenderer ErrorRenderer
+	// The limits on the form values. They are not checked if they are 0.
+	maxKeys         int
+	maxDepth        int
+	maxValuesPerKey int
+	maxBytes        int
 }
 
+// DefaultMaxSliceIndex is the largest index allowed in the sub keys of a slice field unless
+// it is changed with (*Decoder).MaxSliceIndex.
+const DefaultMaxSliceIndex = 1000
+
 func NewDecoder() *Decoder {
-	return &Decoder{strictCase: true}
+	return &Decoder{strictCase: true, maxSliceIndex: DefaultMaxSliceIndex, maxBodySize: DefaultMaxBodySize}
 }
 
 func (d *Decoder) StrictCase(b bool) {
 	d.strictCase = b
 }
 
+// Recurse sets the function used to split keys into sub keys. Sub keys populate struct fields
+// and the entries of map fields. Map keys are lower case if StrictCase is false.
 func (d *Decoder) Recurse(f DecodeSubKeyFunc) {
 	d.recurse = f
 }
 
+// AllowShortArrays allows array fields to be decoded from fewer values than the length of the
+// array. The remaining elements are set to their zero value. By default the number of values
+// must match the length of the array exactly.
+func (d *Decoder) AllowShortArrays(b bool) {
+	d.allowShortArrays = b
+}
+
+// DisallowUnknownFields returns an UnexpectedFieldError for every form key that does not
+// match a field, including sub keys that do not match a field of a sub struct when Recurse is
+// set. Keys added with AllowFields are still ignored.
+func (d *Decoder) DisallowUnknownFields(b bool) {
+	d.disallowUnknownFields = b
+}
+
+// AllowFields adds form keys that are ignored when unknown fields are disallowed, such as a
+// csrf_token added by a framework. The keys are matched against the whole submitted key, and
+// are case insensitive if StrictCase is false.
+func (d *Decoder) AllowFields(keys ...string) {
+	if d.allowedFields == nil {
+		d.allowedFields = make(map[string]bool, len(keys))
+	}
+
+	for _, key := range keys {
+		d.allowedFields[key] = true
+	}
+}
+
+// FailFast stops decoding at the first field that cannot be decoded and returns its error.
+// By default every field is decoded and the errors are returned together as DecodeErrors so
+// that all of the problems with a form can be shown at once.
+func (d *Decoder) FailFast(b bool) {
+	d.failFast = b
+}
+
+// MaxSliceIndex sets the largest index allowed when decoding slices from sub keys, such as
+// items[0][name]. Larger indexes return a SliceIndexError that wraps a LimitError.
+func (d *Decoder) MaxSliceIndex(n int) {
+	d.maxSliceIndex = n
+}
+
+// Location sets the location used to interpret time values that do not include a time zone,
+// such as those from the HTML date and time inputs. The default is UTC.
+func (d *Decoder) Location(loc *time.Location) {
+	d.location = loc
+}
+
 // Parse parses the form values into the supplied variable based on the parsers options.
-// The supplied value must be either a non-nil pointer to a struct or a map.
+// The supplied value must be either a non-nil pointer to a struct or a map. Maps are keyed by
+// the form keys, or the first part of the key if Recurse is set, and the values are decoded
+// in the same way as the entries of a map field. Sub keys can be decoded into a
+// map[string]interface{} which is filled with nested maps, strings and string slices.
 func (p *Decoder) Decode(src map[string][]string, dst interface{}) error {
-	entries, err := buildMap(src, !p.strictCase, p.recurse != nil)
+	return p.decode(src, nil, dst)
+}
+
+// decode decodes the form values and files into dst.
+func (p *Decoder) decode(src url.Values, files map[string][]*multipart.FileHeader, dst interface{}) error {
+	if err := p.checkLimits(src, files); err != nil {
+		return err
+	}
+
+	if gen, ok := p.generatedDecoder(dst); ok {
+		return gen.DecodeForm(src)
+	}
+
+	if rv := reflect.ValueOf(dst); rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Map {
+		vals := buildVals(src, files, !p.strictCase, p.recurse)
+		if err := p.decodeMap(rv.Elem(), vals, fieldRef{opts: &fieldOptions{}}); err != nil {
+			if err == errUnsupportedType {
+				return &UsageTypeError{Type: rv.Type()}
+			}
+
+			return err
+		}
+
+		return nil
+	}
+
+	// Must be a pointer to a struct
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return &UsageTypeError{Type: reflect.TypeOf(dst)}
+	}
+
+	plan, err := cachedStructPlan(rv.Elem().Type(), !p.strictCase, p.recurse != nil)
 	if err != nil {
 		return err
 	}
 
-	vals := buildVals(src, !p.strictCase, p.recurse)
-	return setMap(vals, entries)
+	vals := buildVals(src, files, !p.strictCase, p.recurse)
+	return p.setStruct(rv.Elem(), vals, plan, fieldRef{})
 }
 
-func buildVals(vals url.Values, toLower bool, recurse DecodeSubKeyFunc) map[string]*formLayer {
+func buildVals(vals url.Values, files map[string][]*multipart.FileHeader, toLower bool, recurse DecodeSubKeyFunc) map[string]*formLayer {
 	flatVals := make(map[string]*formLayer)
-	for k, e := range vals {
+	add := func(k string, val *formVal) {
 		key := k
 		if toLower {
 			key = strings.ToLower(k)
@@ -57,12 +178,22 @@ func buildVals(vals url.Values, toLower bool, recurse DecodeSubKeyFunc) map[stri
 
 		if prev := flatVals[key]; prev == nil {
 			// No subkeys yet
-			flatVals[key] = &formLayer{val: &formVal{key: k, vals: e}}
+			flatVals[key] = &formLayer{val: val}
 		} else {
 			// There are multiple entries for the same key with different cases. Just sacrifice some
 			// of error messaging
-			prev.val.vals = append(prev.val.vals, e...)
+			prev.val.vals = append(prev.val.vals, val.vals...)
+			prev.val.files = append(prev.val.files, val.files...)
 		}
+	}
+
+	for k, e := range vals {
+		add(k, &formVal{key: k, vals: e})
+	} // for
+
+	// Files use the same keys as values so that they are matched to fields in the same way.
+	for k, e := range files {
+		add(k, &formVal{key: k, files: e})
 	} // for
 
 	if recurse == nil {
@@ -96,6 +227,7 @@ func buildVals(vals url.Values, toLower bool, recurse DecodeSubKeyFunc) map[stri
 					currEntry.val = layer.val
 				} else {
 					currEntry.val.vals = append(currEntry.val.vals, layer.val.vals...)
+					currEntry.val.files = append(currEntry.val.files, layer.val.files...)
 				}
 			} else { // There are sub keys so add to the map
 				if currEntry.subVals == nil {
@@ -115,108 +247,673 @@ type formLayer struct {
 	subVals map[string]*formLayer
 }
 
+// firstKey returns the original key of the values in the layer. If the layer only has sub
+// keys then the first of those is used.
+func (l *formLayer) firstKey() string {
+	if l.val != nil {
+		return l.val.key
+	}
+
+	for _, k := range sortedKeys(l.subVals) {
+		if key := l.subVals[k].firstKey(); key != "" {
+			return key
+		}
+	}
+
+	return ""
+}
+
+// eachVal calls f for the values of the layer and all of its sub layers in key order.
+func (l *formLayer) eachVal(f func(val *formVal)) {
+	if l.val != nil {
+		f(l.val)
+	}
+
+	for _, k := range sortedKeys(l.subVals) {
+		l.subVals[k].eachVal(f)
+	}
+}
+
+// vals returns the values of the layer, if it has any.
+func (l *formLayer) vals() []string {
+	if l.val == nil {
+		return nil
+	}
+
+	return l.val.vals
+}
+
 type formVal struct {
 	vals []string
+	// files are the uploaded files of a multipart form with the same key.
+	files []*multipart.FileHeader
 	// preserved original key for errors.
 	key string
 }
 
-func setMap(vals map[string]*formLayer, entries map[string]*formEntry) error {
-	for k, layer := range vals {
-		entry, ok := entries[k]
+func (d *Decoder) setMap(v reflect.Value, vals map[string]*formLayer, plan *structPlan, parent fieldRef) error {
+	if d.recurse == nil {
+		vals = d.groupSliceKeys(vals, plan)
+	}
+
+	var errs DecodeErrors
+	for _, k := range sortedKeys(vals) {
+		layer := vals[k]
+		entry, ok := plan.entries[k]
 		if !ok {
-			// TODO: Error?
+			if err := d.unexpectedFields(k, layer, parent, plan); err != nil {
+				if d.failFast {
+					return err
+				}
+
+				errs = errs.Add(err)
+			}
+
+			continue
+		}
+
+		if err := d.setEntry(v.FieldByIndex(entry.index), entry, k, layer, parent.field(entry)); err != nil {
+			if d.failFast {
+				return err
+			}
+
+			errs = errs.Add(err)
+		}
+	}
+
+	// Fields that are not in the form values get their default or are reported as required.
+	for _, k := range plan.keys {
+		if _, ok := vals[k]; ok {
 			continue
 		}
 
-		if layer.val != nil {
-			// If nil we can now set the field.
-			if entry.field.Value.Kind() == reflect.Ptr && entry.field.Value.IsNil() && entry.field.Value.CanSet() {
-				if entry.field.Value.IsNil() && entry.field.Value.CanSet() {
-					entry.field.Value.Set(reflect.New(entry.field.Value.Type().Elem()))
-				} // if
-			} // if
+		entry := plan.entries[k]
+		if err := d.setMissing(v.FieldByIndex(entry.index), entry, k, parent.field(entry)); err != nil {
+			if d.failFast {
+				return err
+			}
 
-			if fieldParser, ok := getFieldParser(entry.field.Value); ok {
-				if err := fieldParser.ParseField(k, layer.val.vals); err != nil {
-					return &FieldParseError{Field: entry.field.Name, Err: err}
-				} // if
+			errs = errs.Add(err)
+		}
+	}
 
-				return nil
-			} // if
+	return errs.Err()
+}
 
-			// We have a few kinds
-			v := baseElem(entry.field.Value)
-			kind := v.Kind()
-			if !v.CanSet() {
-				return nil
+// setMissing applies the default and required options to the field v that is not in the
+// form values. The fields of sub structs are checked in the same way.
+func (d *Decoder) setMissing(v reflect.Value, entry *formEntry, key string, ref fieldRef) error {
+	switch {
+	case entry.opts.hasDefault:
+		layer := &formLayer{val: &formVal{vals: []string{entry.opts.defaultVal}}}
+		if err := d.decodeValue(v, key, layer.val.vals, ref.opts); err != nil {
+			return ref.error(v.Type(), layer, err)
+		}
+
+		if err := d.validate(v, ref.opts.rules); err != nil {
+			return ref.error(v.Type(), layer, err)
+		}
+
+		return nil
+
+	case entry.opts.required:
+		return &RequiredFieldError{Field: ref.name, Path: ref.path}
+	}
+
+	if entry.sub != nil {
+		return d.setStruct(v, nil, entry.sub, ref)
+	}
+
+	return nil
+}
+
+// setEntry sets the field v from a layer of the form values.
+func (d *Decoder) setEntry(v reflect.Value, entry *formEntry, key string, layer *formLayer, ref fieldRef) error {
+	if !v.CanSet() {
+		return nil
+	}
+
+	if layer.subVals != nil && isList(v.Type()) {
+		if style, _ := sliceStyle(d.sliceStyle, ref.opts); style == SliceBrackets {
+			layer = bracketLayer(layer)
+		}
+	}
+
+	var errs DecodeErrors
+	if layer.val != nil {
+		if err := d.decodeFormVal(v, key, layer.val, ref.opts); err != nil {
+			err = ref.error(v.Type(), layer, err)
+			if d.failFast {
+				return err
 			}
 
-			switch kind {
-			case reflect.Bool:
-				b, err := parseBool(layer.val.vals)
-				if err != nil {
-					return &FieldParseError{Field: entry.field.Name, Err: err}
-				}
+			errs = errs.Add(err)
+		}
+	}
 
-				v.SetBool(b)
+	if layer.subVals != nil {
+		var err error
+		if entry.sub != nil {
+			err = d.setStruct(v, layer.subVals, entry.sub, ref)
+		} else if canHoldSubKeys(v.Type()) {
+			err = d.decodeSubKeys(v, layer.subVals, ref)
+			if err == errUnsupportedType {
+				err = ref.error(v.Type(), layer, err)
+			}
+		} else {
+			err = d.unexpectedSubKeys(layer.subVals, ref)
+		}
 
-			case reflect.Int,
-				reflect.Int8,
-				reflect.Int16,
-				reflect.Int32,
-				reflect.Int64:
-				i, err := parseInt(layer.val.vals)
-				if err != nil {
-					return &FieldParseError{Field: entry.field.Name, Err: err}
-				}
+		if err != nil {
+			if d.failFast {
+				return err
+			}
 
-				v.SetInt(i)
+			errs = errs.Add(err)
+		}
+	}
 
-			case reflect.Uint,
-				reflect.Uint8,
-				reflect.Uint16,
-				reflect.Uint32,
-				reflect.Uint64:
-				i, err := parseUint(layer.val.vals)
-				if err != nil {
-					return &FieldParseError{Field: entry.field.Name, Err: err}
-				}
+	if len(errs) == 0 && len(ref.opts.rules) > 0 {
+		if err := d.validate(v, ref.opts.rules); err != nil {
+			return ref.error(v.Type(), layer, err)
+		}
+	}
 
-				v.SetUint(i)
+	return errs.Err()
+}
 
-			case reflect.Slice:
-				elem := v.Type().Elem()
-				if elem.Kind() != reflect.String {
-					return &FieldTypeError{Field: entry.field.Name, Type: entry.field.Value.Type()}
-				}
+// fieldRef describes where a value is being decoded. It holds the options for the field and
+// is used to build errors.
+type fieldRef struct {
+	// name is the form name of the field that holds the value.
+	name string
+	// path is the full Go path of the value, such as Billing.Address.Zip or Items[0].SKU.
+	path string
+	opts *fieldOptions
+}
 
-				// Copy all of the elements into the new string type
-				// The inner string type has been aliased. We need to convert each element
-				set := reflect.MakeSlice(v.Type(), len(layer.val.vals), len(layer.val.vals))
+// field returns the reference for a field of the struct at f.
+func (f fieldRef) field(entry *formEntry) fieldRef {
+	path := entry.goName
+	if f.path != "" {
+		path = f.path + "." + path
+	}
 
-				for i := range layer.val.vals {
-					set.Index(i).SetString(layer.val.vals[i])
-				}
+	return fieldRef{name: entry.name, path: path, opts: entry.opts}
+}
 
-				v.Set(set)
+// index returns the reference for an element of the map or slice at f. The elements of a
+// top level map are treated as fields.
+func (f fieldRef) index(key string) fieldRef {
+	if f.path == "" {
+		return fieldRef{name: key, path: key, opts: f.opts}
+	}
 
-			case reflect.String:
-				s, err := parseString(layer.val.vals)
-				if err != nil {
-					return &FieldParseError{Field: entry.field.Name, Err: err}
+	return fieldRef{name: f.name, path: f.path + "[" + key + "]", opts: f.opts}
+}
+
+// error builds the error for a layer of form values that could not be decoded into a value
+// of type t.
+func (f fieldRef) error(t reflect.Type, layer *formLayer, err error) error {
+	key, vals := layer.firstKey(), layer.vals()
+	if err == errUnsupportedType {
+		return &FieldTypeError{Field: f.name, Path: f.path, Key: key, Vals: vals, Type: t}
+	}
+
+	return &FieldParseError{Field: f.name, Path: f.path, Key: key, Vals: vals, Err: err}
+}
+
+// decodeMap decodes the sub keys of the form values into the map v. The keys are converted
+// to the key type of the map in the same way as a single form value. Nil maps are allocated
+// and existing entries are kept unless they are overwritten.
+func (d *Decoder) decodeMap(v reflect.Value, vals map[string]*formLayer, ref fieldRef) error {
+	allocElem(v)
+	m := baseElem(v)
+	if m.IsNil() {
+		m.Set(reflect.MakeMapWithSize(m.Type(), len(vals)))
+	}
+
+	var errs DecodeErrors
+	for _, k := range sortedKeys(vals) {
+		layer := vals[k]
+		elemRef := ref.index(k)
+
+		key := reflect.New(m.Type().Key()).Elem()
+		if err := d.decodeScalar(key, k, []string{k}, ref.opts); err != nil {
+			if err == errUnsupportedType {
+				return err
+			}
+
+			err = elemRef.error(key.Type(), layer, &MapElemParseError{Key: k, Err: err})
+			if d.failFast {
+				return err
+			}
+
+			errs = errs.Add(err)
+			continue
+		}
+
+		// Entries with only sub keys are skipped unless the map can hold them.
+		if layer.val == nil && !canHoldSubKeys(m.Type().Elem()) {
+			if err := d.unexpectedSubKeys(layer.subVals, elemRef); err != nil {
+				if d.failFast {
+					return err
 				}
 
-				v.SetString(s)
+				errs = errs.Add(err)
+			}
+
+			continue
+		}
+
+		elem := reflect.New(m.Type().Elem()).Elem()
+		if prev := m.MapIndex(key); prev.IsValid() {
+			elem.Set(prev)
+		}
+
+		if err := d.decodeLayer(elem, k, layer, elemRef); err != nil {
+			if err == errUnsupportedType || d.failFast {
+				return err
+			}
+
+			errs = errs.Add(err)
+		}
+
+		m.SetMapIndex(key, elem)
+	}
+
+	return errs.Err()
+}
+
+// decodeLayer decodes a layer of the form values into v. errUnsupportedType is returned
+// as is so that the caller can report the type that it was decoding.
+func (d *Decoder) decodeLayer(v reflect.Value, key string, layer *formLayer, ref fieldRef) error {
+	if layer.val != nil {
+		if layer.subVals != nil && isEmptyInterface(baseType(v.Type())) {
+			// We cannot hold both in an interface
+			return ref.error(v.Type(), layer, &UnexpectedValuesError{layer.val.vals})
+		}
+
+		if err := d.decodeFormVal(v, key, layer.val, ref.opts); err != nil {
+			if err == errUnsupportedType {
+				return err
+			}
+
+			// A value that cannot be set stops the sub keys from being set too.
+			return ref.error(v.Type(), layer, err)
+		}
+	}
+
+	if layer.subVals == nil {
+		return nil
+	}
+
+	if !canHoldSubKeys(v.Type()) {
+		return d.unexpectedSubKeys(layer.subVals, ref)
+	}
+
+	return d.decodeSubKeys(v, layer.subVals, ref)
+}
+
+// unexpectedSubKeys returns an UnexpectedFieldError for the sub keys of a value that cannot
+// hold them, unless unknown fields are allowed.
+func (d *Decoder) unexpectedSubKeys(vals map[string]*formLayer, ref fieldRef) error {
+	var errs DecodeErrors
+	for _, k := range sortedKeys(vals) {
+		if err := d.unexpectedFields(k, vals[k], ref, emptyPlan); err != nil {
+			if d.failFast {
+				return err
+			}
+
+			errs = errs.Add(err)
+		}
+	}
+
+	return errs.Err()
+}
+
+// unexpectedFields returns an UnexpectedFieldError for every form key in a layer that does
+// not match a field, unless unknown fields are allowed. The known entries at the same level
+// are used to suggest the field that was meant.
+func (d *Decoder) unexpectedFields(key string, layer *formLayer, parent fieldRef, plan *structPlan) error {
+	if !d.disallowUnknownFields {
+		return nil
+	}
+
+	path := key
+	if parent.path != "" {
+		path = parent.path + "." + key
+	}
+
+	suggestion := suggestField(key, plan.names)
+
+	var errs DecodeErrors
+	layer.eachVal(func(val *formVal) {
+		if d.isAllowedField(val.key) {
+			return
+		}
+
+		errs = append(errs, &UnexpectedFieldError{
+			Field:      key,
+			Path:       path,
+			Key:        val.key,
+			Vals:       val.vals,
+			Suggestion: suggestion,
+		})
+	})
+
+	if d.failFast && len(errs) > 0 {
+		return errs[0]
+	}
+
+	return errs.Err()
+}
+
+func (d *Decoder) isAllowedField(key string) bool {
+	if d.allowedFields[key] {
+		return true
+	}
+
+	if !d.strictCase {
+		for allowed := range d.allowedFields {
+			if strings.EqualFold(allowed, key) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// decodeSubKeys decodes the sub keys of the form values into v. Structs are decoded by field
+// name, maps by key, slices and arrays by index and empty interfaces into a map[string]interface{}. The
+// sub keys are ignored for any other type.
+func (d *Decoder) decodeSubKeys(v reflect.Value, vals map[string]*formLayer, ref fieldRef) error {
+	switch t := baseType(v.Type()); t.Kind() {
+	case reflect.Interface:
+		if !isEmptyInterface(t) {
+			return nil
+		}
+
+		m := reflect.ValueOf(make(map[string]interface{}, len(vals)))
+		if err := d.decodeMap(m, vals, ref); err != nil {
+			return err
+		}
+
+		allocElem(v)
+		baseElem(v).Set(m)
+
+		return nil
+
+	case reflect.Struct:
+		allocElem(v)
+
+		plan, err := cachedStructPlan(t, !d.strictCase, d.recurse != nil)
+		if err != nil {
+			return err
+		}
+
+		return d.setStruct(baseElem(v), vals, plan, ref)
+
+	case reflect.Map:
+		return d.decodeMap(v, vals, ref)
+
+	case reflect.Slice, reflect.Array:
+		return d.decodeIndexed(v, vals, ref)
+	}
+
+	return nil
+}
+
+// decodeIndexed decodes sub keys that are slice indexes, such as items[0][name], into the
+// slice or array v. The indexes of a slice do not need to be contiguous. The elements are set
+// in index order without any gaps. The elements of an array are set at their index.
+func (d *Decoder) decodeIndexed(v reflect.Value, vals map[string]*formLayer, ref fieldRef) error {
+	type indexedLayer struct {
+		index int
+		key   string
+		layer *formLayer
+	}
+
+	t := baseType(v.Type())
+	isArray := t.Kind() == reflect.Array
+
+	max := d.maxSliceIndex
+	if isArray && t.Len()-1 < max {
+		max = t.Len() - 1
+	}
+
+	elems := make([]indexedLayer, 0, len(vals))
+	for _, k := range sortedKeys(vals) {
+		// Only allow the canonical form so that two keys cannot have the same index
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 || strconv.Itoa(i) != k || (isArray && i > max) {
+			return ref.error(v.Type(), vals[k], &SliceIndexError{Index: k, Max: max})
+		}
+
+		if i > d.maxSliceIndex {
+			limitErr := &LimitError{Limit: "MaxSliceIndex", Max: d.maxSliceIndex, Key: vals[k].firstKey()}
+			return ref.error(v.Type(), vals[k], &SliceIndexError{Index: k, Max: d.maxSliceIndex, Err: limitErr})
+		}
+
+		elems = append(elems, indexedLayer{index: i, key: k, layer: vals[k]})
+	}
+
+	sort.Slice(elems, func(i, j int) bool {
+		return elems[i].index < elems[j].index
+	})
+
+	if isArray && len(elems) < t.Len() && !d.allowShortArrays {
+		return ref.error(v.Type(), elems[0].layer, &ArrayLengthError{Len: t.Len(), Got: len(elems)})
+	}
+
+	allocElem(v)
+	base := baseElem(v)
+
+	var errs DecodeErrors
+	var set reflect.Value
+	if isArray {
+		set = reflect.New(t).Elem()
+	} else {
+		set = reflect.MakeSlice(t, len(elems), len(elems))
+	}
+
+	for i, elem := range elems {
+		if isArray {
+			i = elem.index
+		}
+
+		if err := d.decodeLayer(set.Index(i), elem.key, elem.layer, ref.index(strconv.Itoa(i))); err != nil {
+			if err == errUnsupportedType || d.failFast {
+				return err
+			}
+
+			errs = errs.Add(err)
+		}
+	}
+
+	base.Set(set)
+
+	return errs.Err()
+}
+
+// errUnsupportedType is returned by the decode functions when the value cannot be decoded
+// into. It is converted into a FieldTypeError by the caller, which knows the field.
+var errUnsupportedType = errors.New("unsupported type")
+
+// decodeValue decodes the form values into v. Slices and arrays are decoded element by element
+// unless the type itself implements one of the parsing interfaces. Empty interfaces are set to
+// a string, or a []string if there are multiple values.
+func (d *Decoder) decodeValue(v reflect.Value, key string, vals []string, opts *fieldOptions) error {
+	err := d.decodeScalar(v, key, vals, opts)
+	if err != errUnsupportedType {
+		return err
+	}
+
+	base := baseElem(v)
+
+	if isList(base.Type()) {
+		style, err := sliceStyle(d.sliceStyle, opts)
+		if err != nil {
+			return err
+		}
+
+		if style == SliceComma {
+			vals = splitComma(vals)
+		}
+	}
+
+	var set reflect.Value
+	switch base.Kind() {
+	case reflect.Interface:
+		if base.NumMethod() != 0 {
+			return err
+		}
+
+		if len(vals) == 1 {
+			base.Set(reflect.ValueOf(vals[0]))
+		} else {
+			base.Set(reflect.ValueOf(append([]string(nil), vals...)))
+		}
+
+		return nil
+
+	case reflect.Slice:
+		set = reflect.MakeSlice(base.Type(), len(vals), len(vals))
+
+	case reflect.Array:
+		if len(vals) > base.Len() || (len(vals) < base.Len() && !d.allowShortArrays) {
+			return &ArrayLengthError{Len: base.Len(), Got: len(vals)}
+		}
+
+		set = reflect.New(base.Type()).Elem()
+
+	default:
+		return err
+	}
 
-			default:
-				return &FieldTypeError{Field: entry.field.Name, Type: entry.field.Value.Type()}
+	for i := range vals {
+		if err := d.decodeScalar(set.Index(i), key, vals[i:i+1], opts); err != nil {
+			if err == errUnsupportedType {
+				return err
 			}
+
+			return &ElemParseError{Index: i, Err: err}
+		}
+	}
+
+	base.Set(set)
+
+	return nil
+}
+
+// decodeScalar decodes the form values into a single value. Nil pointers are allocated
+// before decoding.
+func (d *Decoder) decodeScalar(v reflect.Value, key string, vals []string, opts *fieldOptions) error {
+	allocElem(v)
+
+	if fieldParser, ok := getFieldParser(v); ok {
+		return fieldParser.ParseField(key, vals)
+	} // if
+
+	switch base := baseElem(v); base.Type() {
+	case timeType:
+		loc := d.location
+		if loc == nil {
+			loc = time.UTC
+		}
+
+		t, err := parseTime(vals, opts.layout, loc)
+		if err != nil {
+			return err
 		}
 
-		if layer.subVals != nil && entry.subEntries != nil {
-			setMap(layer.subVals, entry.subEntries)
+		base.Set(reflect.ValueOf(t))
+		return nil
+
+	case durationType:
+		dur, err := parseDuration(vals)
+		if err != nil {
+			return err
+		}
+
+		base.SetInt(int64(dur))
+		return nil
+	}
+
+	if textUnmarshaler, ok := getTextUnmarshaler(v); ok {
+		s, err := parseString(vals)
+		if err != nil {
+			return err
+		}
+
+		return textUnmarshaler.UnmarshalText([]byte(s))
+	} // if
+
+	// We have a few kinds
+	v = baseElem(v)
+
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := parseBool(vals)
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+
+	case reflect.Int,
+		reflect.Int8,
+		reflect.Int16,
+		reflect.Int32,
+		reflect.Int64:
+		i, err := parseInt(vals, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(i)
+
+	case reflect.Uint,
+		reflect.Uint8,
+		reflect.Uint16,
+		reflect.Uint32,
+		reflect.Uint64:
+		i, err := parseUint(vals, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetUint(i)
+
+	case reflect.Float32,
+		reflect.Float64:
+		f, err := parseFloat(vals, v.Type().Bits())
+		if err != nil {
+			return err
 		}
+
+		v.SetFloat(f)
+
+	case reflect.Complex64,
+		reflect.Complex128:
+		c, err := parseComplex(vals, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetComplex(c)
+
+	case reflect.String:
+		s, err := parseString(vals)
+		if err != nil {
+			return err
+		}
+
+		v.SetString(s)
+
+	default:
+		return errUnsupportedType
 	}
 
 	return nil
@@ -260,107 +957,114 @@ func getFieldParserOnce(v reflect.Value) (FieldParser, bool) {
 	return nil, false
 } // getFieldParserOnce
 
-func baseElem(v reflect.Value) reflect.Value {
-	// TODO guard against infinite recursion ?
-	for {
-		// Check the pointer in all cases.
-		if v.Kind() != reflect.Ptr {
-			return v
+// getTextUnmarshaler looks for an encoding.TextUnmarshaler in the same way as getFieldParser.
+func getTextUnmarshaler(v reflect.Value) (encoding.TextUnmarshaler, bool) {
+	if textUnmarshaler, ok := getTextUnmarshalerOnce(v); ok {
+		return textUnmarshaler, true
+	}
+
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		if textUnmarshaler, ok := getTextUnmarshalerOnce(v.Elem()); ok {
+			return textUnmarshaler, true
 		}
+	}
 
-		v = v.Elem()
+	if v.CanAddr() {
+		if textUnmarshaler, ok := getTextUnmarshalerOnce(v.Addr()); ok {
+			return textUnmarshaler, true
+		}
 	}
-}
 
-type formEntry struct {
-	field      *Field
-	subEntries map[string]*formEntry
-}
+	return nil, false
+} // getTextUnmarshaler
 
-func buildMap(v interface{}, toLower, recurse bool) (map[string]*formEntry, error) {
-	// Must be a pointer
-	rv := reflect.ValueOf(v)
-	if rv.Kind() != reflect.Ptr || rv.IsNil() {
-		return nil, &UsageTypeError{Type: reflect.TypeOf(v)}
+func getTextUnmarshalerOnce(v reflect.Value) (encoding.TextUnmarshaler, bool) {
+	if v.CanInterface() {
+		if textUnmarshaler, ok := v.Interface().(encoding.TextUnmarshaler); ok {
+			return textUnmarshaler, true
+		}
 	} // if
 
-	// Must be a struct
-	ele := rv.Elem()
-	if ele.Kind() != reflect.Struct {
-		return nil, &UsageTypeError{Type: reflect.TypeOf(v)}
-	} // if
+	return nil, false
+} // getTextUnmarshalerOnce
 
-	entries := make(map[string]*formEntry)
-	if err := addMapEntries(entries, ele, toLower, recurse); err != nil {
-		return nil, err
-	}
+// allocElem allocates any nil pointers in v so that the value they point to can be set.
+func allocElem(v reflect.Value) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			if !v.CanSet() {
+				return
+			}
 
-	return entries, nil
-} // buildMap
+			v.Set(reflect.New(v.Type().Elem()))
+		}
 
-func addMapEntries(entries map[string]*formEntry, ele reflect.Value, toLower, recurse bool) error {
-	eleType := ele.Type()
-	nFields := ele.NumField()
+		v = v.Elem()
+	}
+}
 
-	for i := 0; i < nFields; i++ {
-		entry := ele.Field(i)
-		entryType := eleType.Field(i)
+// sortedKeys returns the keys of the form values in order so that errors are returned in a
+// consistent order.
+func sortedKeys(vals map[string]*formLayer) []string {
+	keys := make([]string, 0, len(vals))
+	for k := range vals {
+		keys = append(keys, k)
+	}
 
-		isUnexported := entryType.PkgPath != ""
-		if isUnexported {
-			continue
-		}
+	sort.Strings(keys)
 
-		name := entryType.Name
-		if tag := entryType.Tag.Get("form"); tag != "" {
-			name = tag
-		}
+	return keys
+}
 
-		keyName := name
-		if toLower {
-			keyName = strings.ToLower(name)
-		}
+// canHoldSubKeys reports whether values of type t can be decoded from sub keys.
+func canHoldSubKeys(t reflect.Type) bool {
+	t = baseType(t)
+	if t == fileType {
+		// Files can only be set from the files of a multipart form.
+		return false
+	}
 
-		if _, ok := entries[keyName]; ok {
-			return &DuplicateFieldError{Field: name}
-		}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	}
 
-		if !entry.CanSet() {
-			continue
-		}
+	return isEmptyInterface(t)
+}
 
-		if !entry.CanInterface() {
-			continue
-		}
+// isList reports whether values of type t are slices or arrays after following all pointers.
+func isList(t reflect.Type) bool {
+	switch baseType(t).Kind() {
+	case reflect.Slice, reflect.Array:
+		return true
+	}
 
-		// Add anonymous structs values at the same level as the current
-		if entryType.Type.Kind() == reflect.Struct && entryType.Anonymous {
-			if err := addMapEntries(entries, entry, toLower, recurse); err != nil {
-				return err
-			}
+	return false
+}
 
-			continue
-		}
+func isEmptyInterface(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface && t.NumMethod() == 0
+}
 
-		fEntry := &formEntry{
-			field: &Field{
-				Value: entry,
-				Name:  name,
-			},
-		}
+// baseType returns the type that t points to after following all pointers.
+func baseType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
 
-		if recurse && entryType.Type.Kind() == reflect.Struct {
-			fEntry.subEntries = make(map[string]*formEntry)
+	return t
+}
 
-			if err := addMapEntries(fEntry.subEntries, entry, toLower, recurse); err != nil {
-				return err
-			}
+func baseElem(v reflect.Value) reflect.Value {
+	// TODO guard against infinite recursion ?
+	for {
+		// Check the pointer in all cases.
+		if v.Kind() != reflect.Ptr {
+			return v
 		}
 
-		entries[keyName] = fEntry
-	} // for
-
-	return nil
+		v = v.Elem()
+	}
 }
 
 // Wrong type passed into Parse
@@ -377,16 +1081,35 @@ func (e *UsageTypeError) Error() string {
 		return buildErrorMessage("Parse", "non-pointer "+e.Type.String())
 	}
 
+	if kind := e.Type.Elem().Kind(); kind != reflect.Struct && kind != reflect.Map {
+		return buildErrorMessage("Parse", "invalid type "+e.Type.String())
+	}
+
 	return buildErrorMessage("Parse", "nil "+e.Type.String())
 }
 
 type FieldTypeError struct {
+	// Field is the form name of the field.
 	Field string
-	Type  reflect.Type
+	// Path is the full path to the value, such as Billing.Address.Zip or Items[0].SKU.
+	Path string
+	// Key is the form key that the values were submitted with.
+	Key string
+	// Vals are the submitted values.
+	Vals []string
+	Type reflect.Type
 }
 
 func (e *FieldTypeError) Error() string {
-	return buildErrorMessage("Parse", "invalid type "+e.Type.String()+" in field "+e.Field)
+	return buildErrorMessage("Parse", "invalid type "+e.Type.String()+" in field "+e.path())
+}
+
+func (e *FieldTypeError) path() string {
+	if e.Path != "" {
+		return e.Path
+	}
+
+	return e.Field
 }
 
 type DuplicateFieldError struct {
@@ -398,21 +1121,37 @@ func (e *DuplicateFieldError) Error() string {
 }
 
 type UnexpectedFieldError struct {
+	// Field is the part of the key that did not match a field.
 	Field string
-	Vals  []string
+	// Path is the full path to where the field was expected, such as Billing.Address.
+	Path string
+	// Key is the form key that the values were submitted with.
+	Key  string
+	Vals []string
+	// Suggestion is the name of a field that is similar to Field, if there is one.
+	Suggestion string
 }
 
 func (e *UnexpectedFieldError) Error() string {
+	key := e.Key
+	if key == "" {
+		key = e.Field
+	}
+
 	var msg string
 	switch len(e.Vals) {
 	case 0:
-		msg = fmt.Sprintf("unexpected field %q", e.Field)
+		msg = fmt.Sprintf("unexpected field %q", key)
 
 	case 1:
-		msg = fmt.Sprintf("unexpected field %q with value %q", e.Field, e.Vals[0])
+		msg = fmt.Sprintf("unexpected field %q with value %q", key, e.Vals[0])
 
 	default:
-		msg = fmt.Sprintf("unexpected field %q with %d values", e.Field, len(e.Vals[0]))
+		msg = fmt.Sprintf("unexpected field %q with %d values", key, len(e.Vals))
+	}
+
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf(", did you mean %q?", e.Suggestion)
 	}
 
 	return buildErrorMessage("Parse", msg)
@@ -434,19 +1173,159 @@ func (e *UnexpectedValuesError) Error() string {
 	return buildErrorMessage("Parse", fmt.Sprintf("unexpected values %v", e.Values))
 }
 
+// ValueRangeError is returned when a value is well formed but does not fit into the
+// bit size of the field.
+type ValueRangeError struct {
+	Value string
+	Bits  int
+}
+
+func (e *ValueRangeError) Error() string {
+	return buildErrorMessage("Parse", fmt.Sprintf("value %v out of range for %d bits", e.Value, e.Bits))
+}
+
+// ArrayLengthError is returned when the number of values does not match the length of an
+// array field.
+type ArrayLengthError struct {
+	Len int
+	Got int
+}
+
+func (e *ArrayLengthError) Error() string {
+	return buildErrorMessage("Parse", fmt.Sprintf("expected %d values for array, found %d", e.Len, e.Got))
+}
+
+// SliceIndexError is returned when the sub key of a slice field is not an index between 0
+// and the maximum slice index.
+type SliceIndexError struct {
+	Index string
+	Max   int
+	// Err is a LimitError if the index is an integer larger than the maximum slice index.
+	Err error
+}
+
+func (e *SliceIndexError) Error() string {
+	return buildErrorMessage("Parse", fmt.Sprintf("invalid slice index %q, must be an integer between 0 and %d", e.Index, e.Max))
+}
+
+func (e *SliceIndexError) Unwrap() error {
+	return e.Err
+}
+
+// ElemParseError is returned when a single element of a slice could not be parsed.
+type ElemParseError struct {
+	Index int
+	Err   error
+}
+
+func (e *ElemParseError) Error() string {
+	return buildErrorMessage("Parse", fmt.Sprintf("error parsing element %d: %q", e.Index, e.Err))
+}
+
+func (e *ElemParseError) Unwrap() error {
+	return e.Err
+}
+
+// MapElemParseError is returned when a single entry of a map could not be parsed.
+type MapElemParseError struct {
+	Key string
+	Err error
+}
+
+func (e *MapElemParseError) Error() string {
+	return buildErrorMessage("Parse", fmt.Sprintf("error parsing map entry %q: %q", e.Key, e.Err))
+}
+
+func (e *MapElemParseError) Unwrap() error {
+	return e.Err
+}
+
+// RequiredFieldError is returned when a field with the required option is not in the form
+// values.
+type RequiredFieldError struct {
+	// Field is the form name of the field.
+	Field string
+	// Path is the full path to the value, such as Billing.Address.Zip or Items[0].SKU.
+	Path string
+}
+
+func (e *RequiredFieldError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = e.Field
+	}
+
+	return buildErrorMessage("Parse", fmt.Sprintf("missing required field %q", path))
+}
+
 type FieldParseError struct {
+	// Field is the form name of the field.
 	Field string
-	Err   error
+	// Path is the full path to the value, such as Billing.Address.Zip or Items[0].SKU.
+	Path string
+	// Key is the form key that the values were submitted with.
+	Key string
+	// Vals are the submitted values.
+	Vals []string
+	Err  error
 }
 
 func (e *FieldParseError) Error() string {
-	return buildErrorMessage("Parse", fmt.Sprintf("error parsing field %q: %q", e.Field, e.Err))
+	return buildErrorMessage("Parse", fmt.Sprintf("error parsing field %q: %q", e.path(), e.Err))
+}
+
+func (e *FieldParseError) path() string {
+	if e.Path != "" {
+		return e.Path
+	}
+
+	return e.Field
 }
 
 func (e *FieldParseError) Unwrap() error {
 	return e.Err
 }
 
+// DecodeErrors holds every error found while decoding the form values, in key order. The
+// individual errors can be ranged over or found with errors.As.
+type DecodeErrors []error
+
+func (e DecodeErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return buildErrorMessage("Parse", fmt.Sprintf("%d errors: %s", len(e), strings.Join(msgs, "; ")))
+}
+
+func (e DecodeErrors) Unwrap() []error {
+	return e
+}
+
+// Add appends err, or the errors it holds if it is also DecodeErrors.
+func (e DecodeErrors) Add(err error) DecodeErrors {
+	if errs, ok := err.(DecodeErrors); ok {
+		return append(e, errs...)
+	}
+
+	return append(e, err)
+}
+
+// Err returns nil if there are no errors. This avoids returning a nil DecodeErrors as a
+// non-nil error.
+func (e DecodeErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
 func buildErrorMessage(fn, msg string) string {
 	return "go-form:" + fn + ": " + msg
 }
@@ -467,34 +1346,103 @@ func parseBool(vals []string) (bool, error) {
 	return false, &UnexpectedValueError{s}
 }
 
-func parseInt(vals []string) (int64, error) {
+// parseInt parses vals into an integer that fits in bitSize bits. A bitSize of 0 is the size
+// of an int.
+func parseInt(vals []string, bitSize int) (int64, error) {
 	s, err := parseString(vals)
 	if err != nil {
 		return 0, err
 	} // if
 
-	i, err := strconv.ParseInt(s, 10, 0)
+	if bitSize == 0 {
+		bitSize = strconv.IntSize
+	}
+
+	i, err := strconv.ParseInt(s, 10, bitSize)
 	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, &ValueRangeError{Value: s, Bits: bitSize}
+		}
+
 		return 0, &UnexpectedValueError{s}
 	} // if
 
 	return i, nil
 }
 
-func parseUint(vals []string) (uint64, error) {
+// parseUint parses vals in the same way as parseInt for unsigned integers.
+func parseUint(vals []string, bitSize int) (uint64, error) {
 	s, err := parseString(vals)
 	if err != nil {
 		return 0, err
 	} // if
 
-	u, err := strconv.ParseUint(s, 10, 0)
+	if bitSize == 0 {
+		bitSize = strconv.IntSize
+	}
+
+	u, err := strconv.ParseUint(s, 10, bitSize)
 	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, &ValueRangeError{Value: s, Bits: bitSize}
+		}
+
 		return 0, &UnexpectedValueError{s}
 	} // if
 
 	return u, nil
 }
 
+func parseFloat(vals []string, bitSize int) (float64, error) {
+	s, err := parseString(vals)
+	if err != nil {
+		return 0, err
+	} // if
+
+	f, err := strconv.ParseFloat(s, bitSize)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, &ValueRangeError{Value: s, Bits: bitSize}
+		}
+
+		return 0, &UnexpectedValueError{s}
+	} // if
+
+	return f, nil
+}
+
+func parseComplex(vals []string, bitSize int) (complex128, error) {
+	s, err := parseString(vals)
+	if err != nil {
+		return 0, err
+	} // if
+
+	c, err := strconv.ParseComplex(s, bitSize)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, &ValueRangeError{Value: s, Bits: bitSize}
+		}
+
+		return 0, &UnexpectedValueError{s}
+	} // if
+
+	return c, nil
+}
+
+func parseDuration(vals []string) (time.Duration, error) {
+	s, err := parseString(vals)
+	if err != nil {
+		return 0, err
+	} // if
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, &UnexpectedValueError{s}
+	} // if
+
+	return d, nil
+}
+
 func parseString(vals []string) (string, error) {
 	if len(vals) == 1 {
 		return vals[0], nil
@@ -2,8 +2,10 @@ package form
 
 import (
 	"errors"
+	"net"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -150,6 +152,58 @@ func TestParseInt(t *testing.T) {
 			t.Errorf("Unexpected value in unexpectedValueError. Expected \"notanint\" found %q", unexpectedValueError.Value)
 		}
 	}
+
+	overflow := struct {
+		Int8   int8
+		Int16  int16
+		Int32  int32
+		Uint8  uint8
+		Uint16 uint16
+		Uint32 uint32
+		Uint64 uint64
+		Int8s  []int8
+	}{}
+
+	tests := []struct {
+		field string
+		value string
+		bits  int
+	}{
+		{"Int8", "300", 8},
+		{"Int8", "-129", 8},
+		{"Int16", "32768", 16},
+		{"Int32", "2147483648", 32},
+		{"Uint8", "256", 8},
+		{"Uint16", "65536", 16},
+		{"Uint32", "4294967296", 32},
+		{"Uint64", "18446744073709551616", 64},
+		{"Int8s", "128", 8},
+	}
+
+	for _, test := range tests {
+		vals = url.Values{
+			test.field: []string{test.value},
+		}
+
+		var valueRangeErr *ValueRangeError
+		if err := NewDecoder().Decode(vals, &overflow); !errors.As(err, &fieldParseErr) || !errors.As(err, &valueRangeErr) {
+			t.Errorf("Decode %s=%s not a range error: %q", test.field, test.value, err)
+		} else if fieldParseErr.Field != test.field || valueRangeErr.Bits != test.bits {
+			t.Errorf("Unexpected range error for %s=%s. Expected %d bits found %s with %d bits", test.field, test.value, test.bits, fieldParseErr.Field, valueRangeErr.Bits)
+		}
+	}
+
+	vals = url.Values{
+		"Int8":  []string{"-128"},
+		"Uint8": []string{"255"},
+	}
+	if err := NewDecoder().Decode(vals, &overflow); err != nil {
+		t.Fatalf("NewDecoder().Decode: %q", err)
+	}
+
+	if overflow.Int8 != -128 || overflow.Uint8 != 255 {
+		t.Errorf("Unexpected limits. Expected -128 and 255 found %d and %d", overflow.Int8, overflow.Uint8)
+	}
 }
 
 func TestParseRecursive(t *testing.T) {
@@ -215,3 +269,1137 @@ func TestParseAnonymous(t *testing.T) {
 		t.Errorf("Unexpected value in outer.InnerValue. Expected %q, found %q", innerVal, outer.InnerValue)
 	}
 }
+
+func TestParseFloat(t *testing.T) {
+	testStruct := struct {
+		Float32    float32
+		Float64    float64
+		Complex64  complex64
+		Complex128 complex128
+	}{}
+
+	vals := url.Values{
+		"Float32":    []string{"1.5"},
+		"Float64":    []string{"-2.25e3"},
+		"Complex64":  []string{"1+2i"},
+		"Complex128": []string{"(3.5-4i)"},
+	}
+	if err := NewDecoder().Decode(vals, &testStruct); err != nil {
+		t.Fatalf("NewDecoder().Decode: %q", err)
+	}
+
+	if testStruct.Float32 != 1.5 {
+		t.Errorf("Unexpected value in Float32. Expected 1.5, found %v", testStruct.Float32)
+	}
+	if testStruct.Float64 != -2250 {
+		t.Errorf("Unexpected value in Float64. Expected -2250, found %v", testStruct.Float64)
+	}
+	if testStruct.Complex64 != 1+2i {
+		t.Errorf("Unexpected value in Complex64. Expected (1+2i), found %v", testStruct.Complex64)
+	}
+	if testStruct.Complex128 != 3.5-4i {
+		t.Errorf("Unexpected value in Complex128. Expected (3.5-4i), found %v", testStruct.Complex128)
+	}
+
+	// Fits in a float64 but not a float32
+	vals = url.Values{
+		"Float32": []string{"1e100"},
+	}
+	var fieldParseErr *FieldParseError
+	var valueRangeErr *ValueRangeError
+	if err := NewDecoder().Decode(vals, &testStruct); !errors.As(err, &fieldParseErr) || !errors.As(err, &valueRangeErr) {
+		t.Fatalf("NewDecoder().Decode not a range error: %q", err)
+	} else {
+		if fieldParseErr.Field != "Float32" {
+			t.Errorf("Unexpected field in fieldParseErr. Expected \"Float32\" found %q", fieldParseErr.Field)
+		}
+
+		if valueRangeErr.Bits != 32 {
+			t.Errorf("Unexpected bits in valueRangeErr. Expected 32 found %d", valueRangeErr.Bits)
+		}
+	}
+
+	vals = url.Values{
+		"Float64": []string{"notafloat"},
+	}
+	var unexpectedValueError *UnexpectedValueError
+	if err := NewDecoder().Decode(vals, &testStruct); !errors.As(err, &unexpectedValueError) {
+		t.Fatalf("NewDecoder().Decode not an unexpected value error: %q", err)
+	}
+}
+
+type upperText string
+
+func (u *upperText) UnmarshalText(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("empty")
+	}
+
+	*u = upperText(strings.ToUpper(string(b)))
+	return nil
+}
+
+func TestParseTextUnmarshaler(t *testing.T) {
+	testStruct := struct {
+		IP    net.IP
+		Upper upperText
+		Ptr   *upperText
+		After string
+	}{}
+
+	vals := url.Values{
+		"IP":    []string{"192.168.0.1"},
+		"Upper": []string{"upper"},
+		"Ptr":   []string{"ptr"},
+		"After": []string{"after"},
+	}
+	if err := NewDecoder().Decode(vals, &testStruct); err != nil {
+		t.Fatalf("NewDecoder().Decode: %q", err)
+	}
+
+	if !testStruct.IP.Equal(net.IPv4(192, 168, 0, 1)) {
+		t.Errorf("Unexpected value in IP. Expected 192.168.0.1, found %v", testStruct.IP)
+	}
+	if testStruct.Upper != "UPPER" {
+		t.Errorf("Unexpected value in Upper. Expected \"UPPER\", found %q", testStruct.Upper)
+	}
+	if testStruct.Ptr == nil || *testStruct.Ptr != "PTR" {
+		t.Errorf("Unexpected value in Ptr. Expected \"PTR\", found %v", testStruct.Ptr)
+	}
+	if testStruct.After != "after" {
+		t.Errorf("Unexpected value in After. Expected \"after\", found %q", testStruct.After)
+	}
+
+	vals = url.Values{
+		"IP": []string{"notanip"},
+	}
+	var fieldParseErr *FieldParseError
+	if err := NewDecoder().Decode(vals, &testStruct); !errors.As(err, &fieldParseErr) {
+		t.Fatalf("NewDecoder().Decode not a parse error: %q", err)
+	} else if fieldParseErr.Field != "IP" {
+		t.Errorf("Unexpected field in fieldParseErr. Expected \"IP\" found %q", fieldParseErr.Field)
+	}
+}
+
+type colourParser string
+
+func (c *colourParser) ParseField(key string, vals []string) error {
+	if len(vals) != 1 {
+		return &UnexpectedValuesError{vals}
+	}
+
+	switch vals[0] {
+	case "red", "green", "blue":
+		*c = colourParser(vals[0])
+		return nil
+	}
+
+	return &UnexpectedValueError{vals[0]}
+}
+
+func TestParseScalarSlice(t *testing.T) {
+	type Enum int
+	testStruct := struct {
+		IDs     []int64
+		Flags   []bool
+		Prices  []float64
+		Enums   []Enum
+		Ptrs    []*uint
+		Colours []colourParser
+	}{}
+
+	vals := url.Values{
+		"IDs":     []string{"1", "2", "3"},
+		"Flags":   []string{"true", "false"},
+		"Prices":  []string{"1.5", "2"},
+		"Enums":   []string{"4", "5"},
+		"Ptrs":    []string{"6"},
+		"Colours": []string{"red", "blue"},
+	}
+	if err := NewDecoder().Decode(vals, &testStruct); err != nil {
+		t.Fatalf("NewDecoder().Decode: %q", err)
+	}
+
+	if want := []int64{1, 2, 3}; !reflect.DeepEqual(testStruct.IDs, want) {
+		t.Errorf("Unexpected value in IDs. Expected %v found %v", want, testStruct.IDs)
+	}
+	if want := []bool{true, false}; !reflect.DeepEqual(testStruct.Flags, want) {
+		t.Errorf("Unexpected value in Flags. Expected %v found %v", want, testStruct.Flags)
+	}
+	if want := []float64{1.5, 2}; !reflect.DeepEqual(testStruct.Prices, want) {
+		t.Errorf("Unexpected value in Prices. Expected %v found %v", want, testStruct.Prices)
+	}
+	if want := []Enum{4, 5}; !reflect.DeepEqual(testStruct.Enums, want) {
+		t.Errorf("Unexpected value in Enums. Expected %v found %v", want, testStruct.Enums)
+	}
+	if len(testStruct.Ptrs) != 1 || testStruct.Ptrs[0] == nil || *testStruct.Ptrs[0] != 6 {
+		t.Errorf("Unexpected value in Ptrs. Expected [6] found %v", testStruct.Ptrs)
+	}
+	if want := []colourParser{"red", "blue"}; !reflect.DeepEqual(testStruct.Colours, want) {
+		t.Errorf("Unexpected value in Colours. Expected %v found %v", want, testStruct.Colours)
+	}
+
+	vals = url.Values{
+		"IDs": []string{"1", "two", "3"},
+	}
+	var fieldParseErr *FieldParseError
+	var elemParseErr *ElemParseError
+	if err := NewDecoder().Decode(vals, &testStruct); !errors.As(err, &fieldParseErr) || !errors.As(err, &elemParseErr) {
+		t.Fatalf("NewDecoder().Decode not an element parse error: %q", err)
+	} else {
+		if fieldParseErr.Field != "IDs" {
+			t.Errorf("Unexpected field in fieldParseErr. Expected \"IDs\" found %q", fieldParseErr.Field)
+		}
+
+		if elemParseErr.Index != 1 {
+			t.Errorf("Unexpected index in elemParseErr. Expected 1 found %d", elemParseErr.Index)
+		}
+	}
+
+	vals = url.Values{
+		"Colours": []string{"red", "purple"},
+	}
+	if err := NewDecoder().Decode(vals, &testStruct); !errors.As(err, &elemParseErr) {
+		t.Fatalf("NewDecoder().Decode not an element parse error: %q", err)
+	} else if elemParseErr.Index != 1 {
+		t.Errorf("Unexpected index in elemParseErr. Expected 1 found %d", elemParseErr.Index)
+	}
+
+	sliceOfSlices := struct {
+		A [][]string
+	}{}
+	vals = url.Values{
+		"A": []string{"a"},
+	}
+	var fieldTypeErr *FieldTypeError
+	if err := NewDecoder().Decode(vals, &sliceOfSlices); !errors.As(err, &fieldTypeErr) {
+		t.Fatalf("NewDecoder().Decode not a field type error: %q", err)
+	}
+}
+
+func TestParseArray(t *testing.T) {
+	testStruct := struct {
+		Phone [3]string
+		RGB   [3]uint8
+	}{}
+
+	vals := url.Values{
+		"Phone": []string{"020", "7946", "0000"},
+		"RGB":   []string{"255", "128", "64"},
+	}
+	if err := NewDecoder().Decode(vals, &testStruct); err != nil {
+		t.Fatalf("NewDecoder().Decode: %q", err)
+	}
+
+	if want := [3]string{"020", "7946", "0000"}; testStruct.Phone != want {
+		t.Errorf("Unexpected value in Phone. Expected %v found %v", want, testStruct.Phone)
+	}
+	if want := [3]uint8{255, 128, 64}; testStruct.RGB != want {
+		t.Errorf("Unexpected value in RGB. Expected %v found %v", want, testStruct.RGB)
+	}
+
+	vals = url.Values{
+		"RGB": []string{"255", "128"},
+	}
+	var arrayLengthErr *ArrayLengthError
+	if err := NewDecoder().Decode(vals, &testStruct); !errors.As(err, &arrayLengthErr) {
+		t.Fatalf("NewDecoder().Decode not an array length error: %q", err)
+	} else if arrayLengthErr.Len != 3 || arrayLengthErr.Got != 2 {
+		t.Errorf("Unexpected lengths in arrayLengthErr. Expected 3 and 2 found %d and %d", arrayLengthErr.Len, arrayLengthErr.Got)
+	}
+
+	d := NewDecoder()
+	d.AllowShortArrays(true)
+	if err := d.Decode(vals, &testStruct); err != nil {
+		t.Fatalf("Decode: %q", err)
+	}
+	if want := [3]uint8{255, 128, 0}; testStruct.RGB != want {
+		t.Errorf("Unexpected value in RGB. Expected %v found %v", want, testStruct.RGB)
+	}
+
+	vals = url.Values{
+		"RGB": []string{"1", "2", "3", "4"},
+	}
+	if err := d.Decode(vals, &testStruct); !errors.As(err, &arrayLengthErr) {
+		t.Fatalf("Decode not an array length error: %q", err)
+	}
+}
+
+func TestParseMap(t *testing.T) {
+	type Item struct {
+		Name string
+		Qty  int
+	}
+
+	testStruct := struct {
+		Attrs  map[string]string
+		Counts map[string]int
+		ByID   map[int]string
+		Items  map[string]Item
+		Ptrs   map[string]*Item
+		Nested map[string]map[string]int
+	}{
+		Attrs: map[string]string{"existing": "value"},
+	}
+
+	vals := url.Values{
+		"Attrs[color]":            []string{"red"},
+		"Attrs[size]":             []string{"L"},
+		"Counts[a]":               []string{"1"},
+		"ByID[2]":                 []string{"two"},
+		"Items[first][Name]":      []string{"first"},
+		"Items[first][Qty]":       []string{"3"},
+		"Ptrs[second][Name]":      []string{"second"},
+		"Nested[outer][inner]":    []string{"4"},
+		"Nested[outer][another]":  []string{"5"},
+		"Nested[outer2][another]": []string{"6"},
+	}
+
+	d := NewDecoder()
+	d.Recurse(ListMapDecodeFunc)
+	if err := d.Decode(vals, &testStruct); err != nil {
+		t.Fatalf("Decode: %q", err)
+	}
+
+	if want := map[string]string{"existing": "value", "color": "red", "size": "L"}; !reflect.DeepEqual(testStruct.Attrs, want) {
+		t.Errorf("Unexpected value in Attrs. Expected %v found %v", want, testStruct.Attrs)
+	}
+	if want := map[string]int{"a": 1}; !reflect.DeepEqual(testStruct.Counts, want) {
+		t.Errorf("Unexpected value in Counts. Expected %v found %v", want, testStruct.Counts)
+	}
+	if want := map[int]string{2: "two"}; !reflect.DeepEqual(testStruct.ByID, want) {
+		t.Errorf("Unexpected value in ByID. Expected %v found %v", want, testStruct.ByID)
+	}
+	if want := map[string]Item{"first": {Name: "first", Qty: 3}}; !reflect.DeepEqual(testStruct.Items, want) {
+		t.Errorf("Unexpected value in Items. Expected %v found %v", want, testStruct.Items)
+	}
+	if want := map[string]*Item{"second": {Name: "second"}}; !reflect.DeepEqual(testStruct.Ptrs, want) {
+		t.Errorf("Unexpected value in Ptrs. Expected %v found %v", want, testStruct.Ptrs)
+	}
+	if want := map[string]map[string]int{"outer": {"inner": 4, "another": 5}, "outer2": {"another": 6}}; !reflect.DeepEqual(testStruct.Nested, want) {
+		t.Errorf("Unexpected value in Nested. Expected %v found %v", want, testStruct.Nested)
+	}
+
+	vals = url.Values{
+		"Counts[b]": []string{"notanint"},
+	}
+	var fieldParseErr *FieldParseError
+	var mapElemParseErr *MapElemParseError
+	if err := d.Decode(vals, &testStruct); !errors.As(err, &fieldParseErr) {
+		t.Fatalf("Decode not a field parse error: %q", err)
+	} else {
+		if fieldParseErr.Field != "Counts" {
+			t.Errorf("Unexpected field in fieldParseErr. Expected \"Counts\" found %q", fieldParseErr.Field)
+		}
+
+		if fieldParseErr.Path != "Counts[b]" {
+			t.Errorf("Unexpected path in fieldParseErr. Expected \"Counts[b]\" found %q", fieldParseErr.Path)
+		}
+	}
+
+	vals = url.Values{
+		"ByID[two]": []string{"two"},
+	}
+	if err := d.Decode(vals, &testStruct); !errors.As(err, &mapElemParseErr) {
+		t.Fatalf("Decode not a map element parse error: %q", err)
+	} else if mapElemParseErr.Key != "two" {
+		t.Errorf("Unexpected key in mapElemParseErr. Expected \"two\" found %q", mapElemParseErr.Key)
+	}
+}
+
+func TestParseMapDst(t *testing.T) {
+	vals := url.Values{
+		"a":       []string{"1"},
+		"b":       []string{"2", "3"},
+		"c[d]":    []string{"4"},
+		"c[e][f]": []string{"5", "6"},
+	}
+
+	strs := map[string]string{}
+	var unexpectedValuesErr *UnexpectedValuesError
+	if err := NewDecoder().Decode(url.Values{"a": vals["a"]}, &strs); err != nil {
+		t.Fatalf("NewDecoder().Decode: %q", err)
+	} else if want := map[string]string{"a": "1"}; !reflect.DeepEqual(strs, want) {
+		t.Errorf("Unexpected value. Expected %v found %v", want, strs)
+	}
+	if err := NewDecoder().Decode(vals, &strs); !errors.As(err, &unexpectedValuesErr) {
+		t.Errorf("NewDecoder().Decode not an unexpected values error: %q", err)
+	}
+
+	var slices map[string][]string
+	if err := NewDecoder().Decode(vals, &slices); err != nil {
+		t.Fatalf("NewDecoder().Decode: %q", err)
+	} else if want := map[string][]string(vals); !reflect.DeepEqual(slices, want) {
+		t.Errorf("Unexpected value. Expected %v found %v", want, slices)
+	}
+
+	var ifaces map[string]interface{}
+	d := NewDecoder()
+	d.Recurse(ListMapDecodeFunc)
+	if err := d.Decode(vals, &ifaces); err != nil {
+		t.Fatalf("Decode: %q", err)
+	}
+
+	want := map[string]interface{}{
+		"a": "1",
+		"b": []string{"2", "3"},
+		"c": map[string]interface{}{
+			"d": "4",
+			"e": map[string]interface{}{
+				"f": []string{"5", "6"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(ifaces, want) {
+		t.Errorf("Unexpected value. Expected %v found %v", want, ifaces)
+	}
+
+	vals = url.Values{
+		"a":    []string{"1"},
+		"a[b]": []string{"2"},
+	}
+	if err := d.Decode(vals, &ifaces); !errors.As(err, &unexpectedValuesErr) {
+		t.Errorf("Decode not an unexpected values error: %q", err)
+	}
+
+	var usageTypeErr *UsageTypeError
+	var chans map[string]chan int
+	if err := d.Decode(vals, &chans); !errors.As(err, &usageTypeErr) {
+		t.Errorf("Decode not a usage type error: %q", err)
+	}
+}
+
+func TestParseIndexedSlice(t *testing.T) {
+	type Item struct {
+		SKU string `form:"sku"`
+		Qty int    `form:"qty"`
+	}
+
+	testStruct := struct {
+		Items  []Item   `form:"items"`
+		Ptrs   []*Item  `form:"ptrs"`
+		Sparse []Item   `form:"sparse"`
+		Names  []string `form:"names"`
+	}{}
+
+	vals := url.Values{
+		"items[0][sku]":  []string{"A"},
+		"items[0][qty]":  []string{"2"},
+		"items[1][sku]":  []string{"B"},
+		"ptrs[0][sku]":   []string{"C"},
+		"sparse[7][sku]": []string{"E"},
+		"sparse[3][sku]": []string{"D"},
+		"names[1]":       []string{"second"},
+		"names[0]":       []string{"first"},
+	}
+
+	d := NewDecoder()
+	d.Recurse(ListMapDecodeFunc)
+	if err := d.Decode(vals, &testStruct); err != nil {
+		t.Fatalf("Decode: %q", err)
+	}
+
+	if want := []Item{{SKU: "A", Qty: 2}, {SKU: "B"}}; !reflect.DeepEqual(testStruct.Items, want) {
+		t.Errorf("Unexpected value in Items. Expected %v found %v", want, testStruct.Items)
+	}
+	if want := []*Item{{SKU: "C"}}; !reflect.DeepEqual(testStruct.Ptrs, want) {
+		t.Errorf("Unexpected value in Ptrs. Expected %v found %v", want, testStruct.Ptrs)
+	}
+	if want := []Item{{SKU: "D"}, {SKU: "E"}}; !reflect.DeepEqual(testStruct.Sparse, want) {
+		t.Errorf("Unexpected value in Sparse. Expected %v found %v", want, testStruct.Sparse)
+	}
+	if want := []string{"first", "second"}; !reflect.DeepEqual(testStruct.Names, want) {
+		t.Errorf("Unexpected value in Names. Expected %v found %v", want, testStruct.Names)
+	}
+
+	var sliceIndexErr *SliceIndexError
+	for _, key := range []string{"items[1001][sku]", "items[-1][sku]", "items[01][sku]", "items[a][sku]"} {
+		vals = url.Values{
+			key: []string{"A"},
+		}
+		if err := d.Decode(vals, &testStruct); !errors.As(err, &sliceIndexErr) {
+			t.Errorf("Decode(%q) not a slice index error: %q", key, err)
+		}
+	}
+
+	d.MaxSliceIndex(2000)
+	vals = url.Values{
+		"items[1001][sku]": []string{"A"},
+	}
+	if err := d.Decode(vals, &testStruct); err != nil {
+		t.Fatalf("Decode: %q", err)
+	}
+
+	vals = url.Values{
+		"items[4][qty]": []string{"notanint"},
+	}
+	var fieldParseErr *FieldParseError
+	if err := d.Decode(vals, &testStruct); !errors.As(err, &fieldParseErr) {
+		t.Fatalf("Decode not a field parse error: %q", err)
+	} else {
+		if fieldParseErr.Path != "Items[0].Qty" {
+			t.Errorf("Unexpected path in fieldParseErr. Expected \"Items[0].Qty\" found %q", fieldParseErr.Path)
+		}
+
+		if fieldParseErr.Key != "items[4][qty]" {
+			t.Errorf("Unexpected key in fieldParseErr. Expected \"items[4][qty]\" found %q", fieldParseErr.Key)
+		}
+	}
+}
+
+func TestParseIndexedArray(t *testing.T) {
+	type Item struct {
+		SKU string `form:"sku"`
+	}
+
+	testStruct := struct {
+		Items [2]Item `form:"items"`
+	}{}
+
+	vals := url.Values{
+		"items[1][sku]": []string{"B"},
+		"items[0][sku]": []string{"A"},
+	}
+
+	d := NewDecoder()
+	d.Recurse(ListMapDecodeFunc)
+	if err := d.Decode(vals, &testStruct); err != nil {
+		t.Fatalf("Decode: %q", err)
+	}
+
+	if want := [2]Item{{SKU: "A"}, {SKU: "B"}}; testStruct.Items != want {
+		t.Errorf("Unexpected value in Items. Expected %v found %v", want, testStruct.Items)
+	}
+
+	var sliceIndexErr *SliceIndexError
+	vals = url.Values{
+		"items[2][sku]": []string{"C"},
+	}
+	if err := d.Decode(vals, &testStruct); !errors.As(err, &sliceIndexErr) {
+		t.Errorf("Decode not a slice index error: %q", err)
+	} else if sliceIndexErr.Max != 1 {
+		t.Errorf("Unexpected max in sliceIndexErr. Expected 1 found %d", sliceIndexErr.Max)
+	}
+
+	var arrayLengthErr *ArrayLengthError
+	vals = url.Values{
+		"items[1][sku]": []string{"B"},
+	}
+	if err := d.Decode(vals, &testStruct); !errors.As(err, &arrayLengthErr) {
+		t.Errorf("Decode not an array length error: %q", err)
+	}
+
+	testStruct.Items = [2]Item{}
+	d.AllowShortArrays(true)
+	if err := d.Decode(vals, &testStruct); err != nil {
+		t.Fatalf("Decode: %q", err)
+	}
+
+	if want := [2]Item{{}, {SKU: "B"}}; testStruct.Items != want {
+		t.Errorf("Unexpected value in Items. Expected %v found %v", want, testStruct.Items)
+	}
+}
+
+func TestParseDecodeErrors(t *testing.T) {
+	type Item struct {
+		Qty int
+	}
+
+	testStruct := struct {
+		A     int
+		B     bool
+		C     string
+		Items []Item
+	}{}
+
+	vals := url.Values{
+		"A":             []string{"notanint"},
+		"B":             []string{"notabool"},
+		"C":             []string{"c"},
+		"Items[0][Qty]": []string{"1"},
+		"Items[1][Qty]": []string{"notanint"},
+	}
+
+	d := NewDecoder()
+	d.Recurse(ListMapDecodeFunc)
+	err := d.Decode(vals, &testStruct)
+
+	var decodeErrs DecodeErrors
+	if !errors.As(err, &decodeErrs) {
+		t.Fatalf("Decode not decode errors: %q", err)
+	}
+
+	if len(decodeErrs) != 3 {
+		t.Fatalf("Unexpected number of errors. Expected 3 found %d: %q", len(decodeErrs), err)
+	}
+
+	wantFields := []string{"A", "B", "Qty"}
+	for i, err := range decodeErrs {
+		var fieldParseErr *FieldParseError
+		if !errors.As(err, &fieldParseErr) {
+			t.Errorf("Error %d not a field parse error: %q", i, err)
+		} else if fieldParseErr.Field != wantFields[i] {
+			t.Errorf("Unexpected field in error %d. Expected %q found %q", i, wantFields[i], fieldParseErr.Field)
+		}
+	}
+
+	// The valid fields are still set
+	if testStruct.C != "c" {
+		t.Errorf("Unexpected value in C. Expected \"c\" found %q", testStruct.C)
+	}
+	if len(testStruct.Items) != 2 || testStruct.Items[0].Qty != 1 {
+		t.Errorf("Unexpected value in Items. Expected 2 items found %v", testStruct.Items)
+	}
+
+	d.FailFast(true)
+	err = d.Decode(vals, &testStruct)
+	if errors.As(err, &decodeErrs) {
+		t.Fatalf("Decode returned decode errors with FailFast: %q", err)
+	}
+
+	var fieldParseErr *FieldParseError
+	if !errors.As(err, &fieldParseErr) || fieldParseErr.Field != "A" {
+		t.Errorf("Unexpected error with FailFast: %q", err)
+	}
+}
+
+func TestParseErrorPaths(t *testing.T) {
+	type Address struct {
+		Zip int `form:"zip"`
+	}
+
+	type Contact struct {
+		Address Address `form:"address"`
+	}
+
+	testStruct := struct {
+		Billing  Contact        `form:"billing"`
+		Shipping Contact        `form:"shipping"`
+		Tags     map[string]int `form:"tags"`
+		IDs      []int          `form:"ids"`
+	}{}
+
+	vals := url.Values{
+		"billing[address][zip]":  []string{"notazip"},
+		"shipping[address][zip]": []string{"1", "2"},
+		"tags[a]":                []string{"notanint"},
+		"ids":                    []string{"1", "notanint"},
+	}
+
+	d := NewDecoder()
+	d.Recurse(ListMapDecodeFunc)
+	err := d.Decode(vals, &testStruct)
+
+	var decodeErrs DecodeErrors
+	if !errors.As(err, &decodeErrs) {
+		t.Fatalf("Decode not decode errors: %q", err)
+	}
+
+	want := []*FieldParseError{
+		{Field: "zip", Path: "Billing.Address.Zip", Key: "billing[address][zip]", Vals: []string{"notazip"}},
+		{Field: "ids", Path: "IDs", Key: "ids", Vals: []string{"1", "notanint"}},
+		{Field: "zip", Path: "Shipping.Address.Zip", Key: "shipping[address][zip]", Vals: []string{"1", "2"}},
+		{Field: "tags", Path: "Tags[a]", Key: "tags[a]", Vals: []string{"notanint"}},
+	}
+	if len(decodeErrs) != len(want) {
+		t.Fatalf("Unexpected number of errors. Expected %d found %d: %q", len(want), len(decodeErrs), err)
+	}
+
+	for i, err := range decodeErrs {
+		var fieldParseErr *FieldParseError
+		if !errors.As(err, &fieldParseErr) {
+			t.Errorf("Error %d not a field parse error: %q", i, err)
+			continue
+		}
+
+		fieldParseErr.Err = nil
+		if !reflect.DeepEqual(fieldParseErr, want[i]) {
+			t.Errorf("Unexpected error %d. Expected %+v found %+v", i, want[i], fieldParseErr)
+		}
+	}
+}
+
+func TestParseDisallowUnknownFields(t *testing.T) {
+	type Address struct {
+		Zip string
+	}
+
+	testStruct := struct {
+		Email   string
+		Name    string
+		Address Address
+	}{}
+
+	vals := url.Values{
+		"Email":         []string{"a@example.com"},
+		"Emial":         []string{"typo"},
+		"csrf_token":    []string{"token"},
+		"Name[first]":   []string{"first"},
+		"Address[Zip]":  []string{"zip"},
+		"Address[Zop]":  []string{"zop"},
+		"Unknown[a][b]": []string{"1", "2"},
+	}
+
+	d := NewDecoder()
+	d.Recurse(ListMapDecodeFunc)
+	if err := d.Decode(vals, &testStruct); err != nil {
+		t.Fatalf("Decode with unknown fields allowed: %q", err)
+	}
+
+	d.DisallowUnknownFields(true)
+	d.AllowFields("csrf_token")
+	err := d.Decode(vals, &testStruct)
+
+	var decodeErrs DecodeErrors
+	if !errors.As(err, &decodeErrs) {
+		t.Fatalf("Decode not decode errors: %q", err)
+	}
+
+	want := []*UnexpectedFieldError{
+		{Field: "Zop", Path: "Address.Zop", Key: "Address[Zop]", Vals: []string{"zop"}, Suggestion: "Zip"},
+		{Field: "Emial", Path: "Emial", Key: "Emial", Vals: []string{"typo"}, Suggestion: "Email"},
+		{Field: "first", Path: "Name.first", Key: "Name[first]", Vals: []string{"first"}},
+		{Field: "Unknown", Path: "Unknown", Key: "Unknown[a][b]", Vals: []string{"1", "2"}},
+	}
+	if len(decodeErrs) != len(want) {
+		t.Fatalf("Unexpected number of errors. Expected %d found %d: %q", len(want), len(decodeErrs), err)
+	}
+
+	for i, err := range decodeErrs {
+		var unexpectedFieldErr *UnexpectedFieldError
+		if !errors.As(err, &unexpectedFieldErr) {
+			t.Errorf("Error %d not an unexpected field error: %q", i, err)
+		} else if !reflect.DeepEqual(unexpectedFieldErr, want[i]) {
+			t.Errorf("Unexpected error %d. Expected %+v found %+v", i, want[i], unexpectedFieldErr)
+		}
+	}
+
+	if !strings.Contains(decodeErrs[1].Error(), `did you mean "Email"?`) {
+		t.Errorf("Unexpected message for error 1: %q", decodeErrs[1])
+	}
+
+	d.StrictCase(false)
+	d.FailFast(true)
+	vals = url.Values{
+		"email":      []string{"a@example.com"},
+		"CSRF_TOKEN": []string{"token"},
+	}
+	if err := d.Decode(vals, &testStruct); err != nil {
+		t.Fatalf("Decode: %q", err)
+	}
+}
+
+func TestParseTagOptions(t *testing.T) {
+	type Address struct {
+		Zip     string `form:",required"`
+		Country string `form:"country,default=NZ"`
+	}
+
+	type testStruct struct {
+		Name    string   `form:"name,required"`
+		Page    int      `form:"page,default=1"`
+		Sort    string   `form:"sort,default="`
+		Tags    []string `form:"tags,default=new"`
+		Secret  string   `form:"-"`
+		Address Address  `form:"address"`
+	}
+
+	vals := url.Values{
+		"name":          []string{"n"},
+		"address[Zip]":  []string{"1010"},
+		"Secret":        []string{"s"},
+		"page":          []string{"3"},
+		"address[more]": []string{"x"},
+	}
+
+	d := NewDecoder()
+	d.Recurse(ListMapDecodeFunc)
+
+	var got testStruct
+	if err := d.Decode(vals, &got); err != nil {
+		t.Fatalf("Decode: %q", err)
+	}
+
+	want := testStruct{
+		Name:    "n",
+		Page:    3,
+		Tags:    []string{"new"},
+		Address: Address{Zip: "1010", Country: "NZ"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected value. Expected %+v found %+v", want, got)
+	}
+
+	got = testStruct{Sort: "name"}
+	err := d.Decode(url.Values{}, &got)
+
+	var decodeErrs DecodeErrors
+	if !errors.As(err, &decodeErrs) {
+		t.Fatalf("Decode not decode errors: %q", err)
+	}
+
+	wantErrs := []*RequiredFieldError{
+		{Field: "Zip", Path: "Address.Zip"},
+		{Field: "name", Path: "Name"},
+	}
+	if len(decodeErrs) != len(wantErrs) {
+		t.Fatalf("Unexpected number of errors. Expected %d found %d: %q", len(wantErrs), len(decodeErrs), err)
+	}
+
+	for i, err := range decodeErrs {
+		var requiredErr *RequiredFieldError
+		if !errors.As(err, &requiredErr) {
+			t.Errorf("Error %d not a required field error: %q", i, err)
+		} else if !reflect.DeepEqual(requiredErr, wantErrs[i]) {
+			t.Errorf("Unexpected error %d. Expected %+v found %+v", i, wantErrs[i], requiredErr)
+		}
+	}
+
+	if got.Page != 1 || got.Sort != "" || got.Address.Country != "NZ" {
+		t.Errorf("Defaults not applied: %+v", got)
+	}
+
+	badDefault := struct {
+		Page int `form:"page,default=one"`
+	}{}
+
+	var fieldParseErr *FieldParseError
+	if err := NewDecoder().Decode(url.Values{}, &badDefault); !errors.As(err, &fieldParseErr) {
+		t.Fatalf("Decode not a field parse error: %q", err)
+	}
+
+	if !reflect.DeepEqual(fieldParseErr.Vals, []string{"one"}) {
+		t.Errorf("Unexpected values in error. Expected %v found %v", []string{"one"}, fieldParseErr.Vals)
+	}
+
+	typo := struct {
+		Name string `form:"name,requird"`
+	}{}
+
+	var optionErr *TagOptionError
+	if err := NewDecoder().Decode(url.Values{"name": {"n"}}, &typo); !errors.As(err, &optionErr) {
+		t.Fatalf("Decode not a tag option error: %q", err)
+	}
+
+	if optionErr.Field != "Name" || optionErr.Option != "requird" {
+		t.Errorf("Unexpected tag option error. Expected Name requird found %s %s", optionErr.Field, optionErr.Option)
+	}
+
+	if status := ErrorStatus(optionErr); status != 500 {
+		t.Errorf("Unexpected status for tag option error. Expected 500 found %d", status)
+	}
+}
+
+func TestParseValidate(t *testing.T) {
+	type testStruct struct {
+		Age     int      `form:"age" validate:"min=18,max=130"`
+		Code    string   `form:"code" validate:"len=3,pattern=^[A-Z]{1,3}$"`
+		Name    string   `form:"name" validate:"minlen=2,maxlen=5"`
+		Colours []string `form:"colours" validate:"maxlen=2,oneof=red green"`
+		Email   string   `form:"email" validate:"email"`
+		Site    string   `form:"site" validate:"url"`
+		Even    int      `form:"even" validate:"even"`
+		Page    int      `form:"page,default=0" validate:"min=1"`
+		Missing string   `form:"missing" validate:"minlen=1"`
+	}
+
+	d := NewDecoder()
+	d.RegisterRule("even", func(v reflect.Value, _ string) error {
+		if v.Int()%2 != 0 {
+			return errors.New("must be even")
+		}
+
+		return nil
+	})
+
+	vals := url.Values{
+		"age":     []string{"30"},
+		"code":    []string{"ABC"},
+		"name":    []string{"Jo"},
+		"colours": []string{"red", "green"},
+		"email":   []string{"jo@example.com"},
+		"site":    []string{"https://example.com/a"},
+		"even":    []string{"4"},
+		"page":    []string{"2"},
+	}
+
+	var got testStruct
+	if err := d.Decode(vals, &got); err != nil {
+		t.Fatalf("Decode: %q", err)
+	}
+
+	vals = url.Values{
+		"age":     []string{"17"},
+		"code":    []string{"AB1"},
+		"name":    []string{"Joseph"},
+		"colours": []string{"red", "blue"},
+		"email":   []string{"Jo <jo@example.com>"},
+		"site":    []string{"/a"},
+		"even":    []string{"3"},
+	}
+
+	err := d.Decode(vals, &got)
+
+	var decodeErrs DecodeErrors
+	if !errors.As(err, &decodeErrs) {
+		t.Fatalf("Decode not decode errors: %q", err)
+	}
+
+	want := []struct {
+		path string
+		rule string
+	}{
+		{"Age", "min"},
+		{"Code", "pattern"},
+		{"Colours", "oneof"},
+		{"Email", "email"},
+		{"Even", "even"},
+		{"Name", "maxlen"},
+		{"Site", "url"},
+		{"Page", "min"},
+	}
+	if len(decodeErrs) != len(want) {
+		t.Fatalf("Unexpected number of errors. Expected %d found %d: %q", len(want), len(decodeErrs), err)
+	}
+
+	for i, err := range decodeErrs {
+		var fieldParseErr *FieldParseError
+		var validationErr *ValidationError
+		if !errors.As(err, &fieldParseErr) || !errors.As(err, &validationErr) {
+			t.Errorf("Error %d not a validation error: %q", i, err)
+			continue
+		}
+
+		if fieldParseErr.Path != want[i].path || validationErr.Rule != want[i].rule {
+			t.Errorf("Unexpected error %d. Expected %s %s found %s %s", i, want[i].path, want[i].rule, fieldParseErr.Path, validationErr.Rule)
+		}
+	}
+
+	if key := decodeErrs[0].(*FieldParseError).Key; key != "age" {
+		t.Errorf("Unexpected key in error. Expected %q found %q", "age", key)
+	}
+
+	unknown := struct {
+		Name string `validate:"nope"`
+	}{}
+
+	var validationErr *ValidationError
+	if err := NewDecoder().Decode(url.Values{"Name": []string{"a"}}, &unknown); !errors.As(err, &validationErr) {
+		t.Errorf("Decode with unknown rule not a validation error: %q", err)
+	}
+}
+
+type hookAddress struct {
+	Zip   string
+	Calls []string
+}
+
+func (a *hookAddress) BeforeDecode() error {
+	a.Calls = append(a.Calls, "before")
+	return nil
+}
+
+func (a *hookAddress) AfterDecode() error {
+	a.Calls = append(a.Calls, "after")
+	a.Zip = strings.TrimSpace(a.Zip)
+	return nil
+}
+
+func (a *hookAddress) Validate() error {
+	a.Calls = append(a.Calls, "validate")
+	if len(a.Zip) != 4 {
+		return errors.New("invalid zip")
+	}
+
+	return nil
+}
+
+type hookOrder struct {
+	Title   string
+	Slug    string
+	Billing hookAddress
+	Items   []hookAddress
+}
+
+func (o *hookOrder) AfterDecode() error {
+	o.Slug = strings.ToLower(strings.ReplaceAll(o.Title, " ", "-"))
+	return nil
+}
+
+func TestParseHooks(t *testing.T) {
+	d := NewDecoder()
+	d.Recurse(ListMapDecodeFunc)
+
+	vals := url.Values{
+		"Title":         []string{"Big Order"},
+		"Billing[Zip]":  []string{" 1010 "},
+		"Items[0][Zip]": []string{"2020"},
+		"Items[1][Zip]": []string{"30"},
+	}
+
+	var got hookOrder
+	err := d.Decode(vals, &got)
+
+	var hookErr *HookError
+	if !errors.As(err, &hookErr) {
+		t.Fatalf("Decode not a hook error: %q", err)
+	}
+
+	if hookErr.Path != "Items[1]" || hookErr.Hook != "Validate" {
+		t.Errorf("Unexpected hook error. Expected Validate at %q found %s at %q", "Items[1]", hookErr.Hook, hookErr.Path)
+	}
+
+	if got.Slug != "" {
+		t.Errorf("AfterDecode called with field errors. Found slug %q", got.Slug)
+	}
+
+	wantCalls := []string{"before", "after", "validate"}
+	if !reflect.DeepEqual(got.Billing.Calls, wantCalls) {
+		t.Errorf("Unexpected calls. Expected %v found %v", wantCalls, got.Billing.Calls)
+	}
+
+	if got.Billing.Zip != "1010" {
+		t.Errorf("Unexpected value in Billing.Zip. Expected %q found %q", "1010", got.Billing.Zip)
+	}
+
+	vals.Set("Items[1][Zip]", "3030")
+
+	got = hookOrder{}
+	if err := d.Decode(vals, &got); err != nil {
+		t.Fatalf("Decode: %q", err)
+	}
+
+	if got.Slug != "big-order" {
+		t.Errorf("Unexpected value in Slug. Expected %q found %q", "big-order", got.Slug)
+	}
+	// The hooks of nested structs run even if they are not in the form values.
+	got = hookOrder{}
+	err = d.Decode(url.Values{"Title": []string{"Big Order"}}, &got)
+	if !errors.As(err, &hookErr) {
+		t.Fatalf("Decode without Billing not a hook error: %q", err)
+	}
+
+	if hookErr.Path != "Billing" || hookErr.Hook != "Validate" {
+		t.Errorf("Unexpected hook error. Expected Validate at %q found %s at %q", "Billing", hookErr.Hook, hookErr.Path)
+	}
+
+	if !reflect.DeepEqual(got.Billing.Calls, wantCalls) {
+		t.Errorf("Unexpected calls without Billing. Expected %v found %v", wantCalls, got.Billing.Calls)
+	}
+}
+
+type benchAddress struct {
+	Street string `form:"street"`
+	City   string `form:"city"`
+	Zip    string `form:"zip" validate:"len=4"`
+}
+
+type benchForm struct {
+	Name    string       `form:"name,required"`
+	Email   string       `form:"email"`
+	Age     int          `form:"age"`
+	Price   float64      `form:"price"`
+	Tags    []string     `form:"tags"`
+	IDs     []int64      `form:"ids"`
+	Agree   bool         `form:"agree"`
+	Page    int          `form:"page,default=1"`
+	Billing benchAddress `form:"billing"`
+}
+
+var benchVals = url.Values{
+	"name":            []string{"Jo"},
+	"email":           []string{"jo@example.com"},
+	"age":             []string{"30"},
+	"price":           []string{"9.99"},
+	"tags":            []string{"a", "b"},
+	"ids":             []string{"1", "2", "3"},
+	"agree":           []string{"true"},
+	"billing[street]": []string{"1 Main St"},
+	"billing[city]":   []string{"Auckland"},
+	"billing[zip]":    []string{"1010"},
+}
+
+func BenchmarkDecode(b *testing.B) {
+	d := NewDecoder()
+	d.Recurse(ListMapDecodeFunc)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var dst benchForm
+		if err := d.Decode(benchVals, &dst); err != nil {
+			b.Fatalf("Decode: %q", err)
+		}
+	}
+}
+
+func BenchmarkDecodeParallel(b *testing.B) {
+	d := NewDecoder()
+	d.Recurse(ListMapDecodeFunc)
+	d.StrictCase(false)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			var dst benchForm
+			if err := d.Decode(benchVals, &dst); err != nil {
+				b.Errorf("Decode: %q", err)
+			}
+		}
+	})
+}
+
+type generatedForm struct {
+	Name      string
+	generated bool
+}
+
+func (f *generatedForm) DecodeForm(vals url.Values) error {
+	f.generated = true
+	return nil
+}
+
+func (generatedForm) FormOptions() GeneratedOptions {
+	return GeneratedOptions{StrictCase: true, Recurse: RecurseListMap}
+}
+
+func TestParseGenerated(t *testing.T) {
+	vals := url.Values{"Name": []string{"n"}}
+	tests := []struct {
+		name  string
+		setup func(d *Decoder)
+		want  bool
+	}{
+		{"matching", func(d *Decoder) {}, true},
+		{"case insensitive", func(d *Decoder) { d.StrictCase(false) }, false},
+		{"no recurse", func(d *Decoder) { d.Recurse(nil) }, false},
+		{"other recurse", func(d *Decoder) { d.Recurse(NestedMapDecodeFunc) }, false},
+		{"custom recurse", func(d *Decoder) { d.Recurse(func(key string) []string { return ListMapDecodeFunc(key) }) }, false},
+		{"fail fast", func(d *Decoder) { d.FailFast(true) }, false},
+		{"unknown fields", func(d *Decoder) { d.DisallowUnknownFields(true) }, false},
+		{"max slice index", func(d *Decoder) { d.MaxSliceIndex(10) }, false},
+	}
+
+	for _, test := range tests {
+		d := NewDecoder()
+		d.Recurse(ListMapDecodeFunc)
+		test.setup(d)
+
+		var dst generatedForm
+		if err := d.Decode(vals, &dst); err != nil {
+			t.Fatalf("Decode %s: %q", test.name, err)
+		}
+
+		if dst.generated != test.want {
+			t.Errorf("Unexpected use of DecodeForm for %s. Expected %t found %t", test.name, test.want, dst.generated)
+		}
+
+		if !test.want && dst.Name != "n" {
+			t.Errorf("Unexpected value in Name for %s. Expected %q found %q", test.name, "n", dst.Name)
+		}
+	}
+
+	var dst generatedForm
+	if err := DecodeWithReflection(vals, &dst, dst.FormOptions()); err != nil || dst.generated || dst.Name != "n" {
+		t.Errorf("DecodeWithReflection used DecodeForm: %+v %v", dst, err)
+	}
+}
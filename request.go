@@ -0,0 +1,331 @@
+package form
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// RequestSource selects the parts of a request that DecodeRequest reads form values from.
+type RequestSource int
+
+const (
+	// QueryAndBody reads the query string and the body. A key in the body replaces the same
+	// key in the query string, so a posted value cannot be overridden from the URL.
+	QueryAndBody RequestSource = iota
+	// QueryOnly reads the query string and leaves the body unread.
+	QueryOnly
+	// BodyOnly reads the body and ignores the query string.
+	BodyOnly
+)
+
+// DefaultMaxBodySize is the largest request body that DecodeRequest reads unless it is
+// changed with (*Decoder).MaxBodySize. It matches the limit that (*http.Request).ParseForm
+// uses for urlencoded bodies, but here it also applies to multipart bodies, so uploads larger
+// than 10 MB need a larger MaxBodySize.
+const DefaultMaxBodySize = 10 << 20
+
+// defaultMaxMemory is the amount of a multipart body that is held in memory, the same as
+// (*http.Request).FormValue. File parts over this are stored in temporary files.
+const defaultMaxMemory = 32 << 20
+
+// RequestSource sets the parts of a request that DecodeRequest reads. The default is
+// QueryAndBody.
+func (d *Decoder) RequestSource(s RequestSource) {
+	d.requestSource = s
+}
+
+// MaxBodySize sets the largest request body that DecodeRequest reads, including the files of
+// multipart bodies. Larger bodies return a BodyTooLargeError. A size of 0 or less removes the
+// limit.
+func (d *Decoder) MaxBodySize(n int64) {
+	d.maxBodySize = n
+}
+
+// DecodeRequest decodes the form values of r into dst in the same way as Decode. The body is
+// parsed based on its Content-Type, which must be application/x-www-form-urlencoded or
+// multipart/form-data. The files of a multipart body are decoded in the same way as
+// DecodeMultipart. A body without a Content-Type is ignored.
+//
+// The parsed body is stored in r.PostForm, and r.MultipartForm for multipart bodies, in the
+// same way as (*http.Request).ParseMultipartForm so that the request can still be used after
+// its body has been read. A body that has already been parsed is read from r.PostForm.
+//
+// Fields of dst with the source option are read from another part of the request instead of
+// the form values:
+//
+//	ID        int    `form:"id,source=path"`
+//	RequestID string `form:"X-Request-Id,source=header"`
+//	Session   string `form:"session,source=cookie"`
+//
+// The name of the field is the name of the path value from r.PathValue, the header or the
+// cookie. Header names are matched case insensitively whatever the StrictCase option. Form
+// values with the same key are ignored so that they cannot be set from the query string or
+// body. Empty path values are treated as missing. Only the fields of dst itself, including
+// embedded structs, can have a source.
+func (d *Decoder) DecodeRequest(r *http.Request, dst interface{}) error {
+	src, files, err := d.requestValues(r)
+	if err != nil {
+		return err
+	}
+
+	if src, err = d.addSourceValues(r, dst, src); err != nil {
+		return err
+	}
+
+	return d.decode(src, files, dst)
+}
+
+// addSourceValues returns vals with the values of the fields of dst that have a source
+// replaced by the values from r. vals is copied before it is changed.
+func (d *Decoder) addSourceValues(r *http.Request, dst interface{}, vals url.Values) (url.Values, error) {
+	t := reflect.TypeOf(dst)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return vals, nil
+	}
+
+	copied := false
+	for _, field := range cachedTypeFields(t.Elem()) {
+		if field.opts.source == "" {
+			continue
+		}
+
+		name := field.opts.name
+
+		var sourceVals []string
+		switch field.opts.source {
+		case "path":
+			if v := r.PathValue(name); v != "" {
+				sourceVals = []string{v}
+			}
+
+		case "header":
+			for k, v := range r.Header {
+				if strings.EqualFold(k, name) {
+					sourceVals = append(sourceVals, v...)
+				}
+			}
+
+		case "cookie":
+			for _, c := range r.Cookies() {
+				if c.Name == name {
+					sourceVals = append(sourceVals, c.Value)
+				}
+			}
+
+		default:
+			return nil, &SourceError{Field: field.goName, Source: field.opts.source}
+		}
+
+		if !copied {
+			vals = cloneValues(vals)
+			copied = true
+		}
+
+		for k := range vals {
+			if k == name || (!d.strictCase && strings.EqualFold(k, name)) {
+				delete(vals, k)
+			}
+		}
+
+		if sourceVals != nil {
+			vals[name] = sourceVals
+		}
+	}
+
+	return vals, nil
+}
+
+func cloneValues(vals url.Values) url.Values {
+	clone := make(url.Values, len(vals))
+	for k, v := range vals {
+		clone[k] = v
+	}
+
+	return clone
+}
+
+// requestValues returns the form values of r from the parts selected by the request source,
+// and the files of a multipart body.
+func (d *Decoder) requestValues(r *http.Request) (url.Values, map[string][]*multipart.FileHeader, error) {
+	var query url.Values
+	if d.requestSource != BodyOnly {
+		var err error
+		if query, err = url.ParseQuery(r.URL.RawQuery); err != nil {
+			return nil, nil, &MalformedQueryError{Err: err}
+		}
+	}
+
+	if d.requestSource == QueryOnly {
+		return query, nil, nil
+	}
+
+	body, err := d.readBody(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var files map[string][]*multipart.FileHeader
+	if r.MultipartForm != nil {
+		files = r.MultipartForm.File
+	}
+
+	if len(query) == 0 {
+		return body, files, nil
+	}
+
+	vals := cloneValues(query)
+
+	// The Decoder would merge keys that only differ in case so remove them here. The query
+	// keys are grouped by their lower case form once so that each body key is a lookup.
+	var queryKeys map[string][]string
+	if !d.strictCase {
+		queryKeys = make(map[string][]string, len(query))
+		for q := range query {
+			lower := strings.ToLower(q)
+			queryKeys[lower] = append(queryKeys[lower], q)
+		}
+	}
+
+	for k, v := range body {
+		for _, q := range queryKeys[strings.ToLower(k)] {
+			delete(vals, q)
+		}
+
+		vals[k] = v
+	}
+
+	return vals, files, nil
+}
+
+// readBody parses the body of r.
+func (d *Decoder) readBody(r *http.Request) (url.Values, error) {
+	if r.PostForm != nil {
+		return r.PostForm, nil
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if r.Body == nil || r.Body == http.NoBody || contentType == "" {
+		return nil, nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, &UnsupportedContentTypeError{ContentType: contentType}
+	}
+
+	body := io.Reader(r.Body)
+	if d.maxBodySize > 0 {
+		body = http.MaxBytesReader(nil, r.Body, d.maxBodySize)
+	}
+
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		b, err := io.ReadAll(body)
+		if err != nil {
+			return nil, d.bodyError(mediaType, err)
+		}
+
+		vals, err := url.ParseQuery(string(b))
+		if err != nil {
+			return nil, &MalformedBodyError{ContentType: mediaType, Err: err}
+		}
+
+		r.PostForm = vals
+		return vals, nil
+
+	case "multipart/form-data":
+		boundary := params["boundary"]
+		if boundary == "" {
+			return nil, &MalformedBodyError{ContentType: mediaType, Err: http.ErrMissingBoundary}
+		}
+
+		mf, err := multipart.NewReader(body, boundary).ReadForm(defaultMaxMemory)
+		if err != nil {
+			return nil, d.bodyError(mediaType, err)
+		}
+
+		r.MultipartForm = mf
+		r.PostForm = mf.Value
+		return mf.Value, nil
+	}
+
+	return nil, &UnsupportedContentTypeError{ContentType: mediaType}
+}
+
+// bodyError converts an error from reading a body into a BodyTooLargeError or a
+// MalformedBodyError.
+func (d *Decoder) bodyError(mediaType string, err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return &BodyTooLargeError{Limit: maxBytesErr.Limit}
+	}
+
+	return &MalformedBodyError{ContentType: mediaType, Err: err}
+}
+
+// MalformedQueryError is returned by DecodeRequest when the query string cannot be parsed.
+type MalformedQueryError struct {
+	Err error
+}
+
+func (e *MalformedQueryError) Error() string {
+	return buildErrorMessage("Parse", fmt.Sprintf("malformed query string: %s", e.Err))
+}
+
+func (e *MalformedQueryError) Unwrap() error {
+	return e.Err
+}
+
+// MalformedBodyError is returned by DecodeRequest when the request body cannot be read or
+// parsed as its Content-Type.
+type MalformedBodyError struct {
+	// ContentType is the media type of the body without its parameters.
+	ContentType string
+	Err         error
+}
+
+func (e *MalformedBodyError) Error() string {
+	return buildErrorMessage("Parse", fmt.Sprintf("malformed %s body: %s", e.ContentType, e.Err))
+}
+
+func (e *MalformedBodyError) Unwrap() error {
+	return e.Err
+}
+
+// BodyTooLargeError is returned by DecodeRequest when the request body is larger than the
+// maximum body size.
+type BodyTooLargeError struct {
+	Limit int64
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return buildErrorMessage("Parse", fmt.Sprintf("request body larger than %d bytes", e.Limit))
+}
+
+// UnsupportedContentTypeError is returned by DecodeRequest when the request body is not a
+// form.
+type UnsupportedContentTypeError struct {
+	ContentType string
+}
+
+func (e *UnsupportedContentTypeError) Error() string {
+	return buildErrorMessage("Parse", fmt.Sprintf("unsupported content type %q", e.ContentType))
+}
+
+// SourceError is returned by DecodeRequest when the source option of a field is not path,
+// header or cookie.
+type SourceError struct {
+	Field  string
+	Source string
+}
+
+func (e *SourceError) Error() string {
+	return buildErrorMessage("Parse", fmt.Sprintf("unknown source %q for field %q", e.Source, e.Field))
+}
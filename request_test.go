@@ -0,0 +1,256 @@
+package form
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type requestForm struct {
+	Name  string
+	Count int
+	Tags  []string
+}
+
+func TestDecodeRequest(t *testing.T) {
+	var multipartBody bytes.Buffer
+	mw := multipart.NewWriter(&multipartBody)
+	mw.WriteField("Name", "body")
+	mw.WriteField("Tags", "a")
+	mw.WriteField("Tags", "b")
+	mw.Close()
+
+	tests := []struct {
+		name        string
+		source      RequestSource
+		contentType string
+		body        string
+		want        requestForm
+	}{
+		{
+			name: "query",
+			want: requestForm{Name: "query", Count: 1},
+		},
+		{
+			name:        "urlencoded",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "Name=body&Tags=a&Tags=b",
+			want:        requestForm{Name: "body", Count: 1, Tags: []string{"a", "b"}},
+		},
+		{
+			name:        "multipart",
+			contentType: mw.FormDataContentType(),
+			body:        multipartBody.String(),
+			want:        requestForm{Name: "body", Count: 1, Tags: []string{"a", "b"}},
+		},
+		{
+			name:        "query only",
+			source:      QueryOnly,
+			contentType: "application/x-www-form-urlencoded",
+			body:        "Name=body",
+			want:        requestForm{Name: "query", Count: 1},
+		},
+		{
+			name:        "body only",
+			source:      BodyOnly,
+			contentType: "application/x-www-form-urlencoded",
+			body:        "Name=body",
+			want:        requestForm{Name: "body"},
+		},
+		{
+			name: "no content type",
+			body: "Name=body",
+			want: requestForm{Name: "query", Count: 1},
+		},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/?Name=query&Count=1", strings.NewReader(test.body))
+		if test.contentType != "" {
+			r.Header.Set("Content-Type", test.contentType)
+		}
+
+		d := NewDecoder()
+		d.RequestSource(test.source)
+
+		var dst requestForm
+		if err := d.DecodeRequest(r, &dst); err != nil {
+			t.Fatalf("DecodeRequest %s: %q", test.name, err)
+		}
+
+		if dst.Name != test.want.Name || dst.Count != test.want.Count || strings.Join(dst.Tags, ",") != strings.Join(test.want.Tags, ",") {
+			t.Errorf("Unexpected value for %s. Expected %+v found %+v", test.name, test.want, dst)
+		}
+	}
+}
+
+func TestDecodeRequestParsed(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/?Count=1", strings.NewReader("Name=body"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var dst requestForm
+	if err := NewDecoder().DecodeRequest(r, &dst); err != nil {
+		t.Fatalf("DecodeRequest: %q", err)
+	}
+
+	if got := r.FormValue("Name"); got != "body" {
+		t.Errorf("Unexpected value in FormValue. Expected \"body\" found %q", got)
+	}
+
+	// The body has been read so the values come from r.PostForm.
+	dst = requestForm{}
+	if err := NewDecoder().DecodeRequest(r, &dst); err != nil {
+		t.Fatalf("DecodeRequest: %q", err)
+	}
+
+	if dst.Name != "body" || dst.Count != 1 {
+		t.Errorf("Unexpected value after parsing. Expected {Name:body Count:1} found %+v", dst)
+	}
+}
+
+func TestDecodeRequestCase(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/?NAME=query&Name=query", strings.NewReader("name=body"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	d := NewDecoder()
+	d.StrictCase(false)
+
+	var dst requestForm
+	if err := d.DecodeRequest(r, &dst); err != nil {
+		t.Fatalf("DecodeRequest: %q", err)
+	}
+
+	if dst.Name != "body" {
+		t.Errorf("Unexpected value in Name. Expected \"body\" found %q", dst.Name)
+	}
+}
+
+func TestDecodeRequestErrors(t *testing.T) {
+	newRequest := func(target, contentType, body string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		return r
+	}
+
+	var dst requestForm
+
+	var queryErr *MalformedQueryError
+	if err := NewDecoder().DecodeRequest(newRequest("/?Name=%zz", "", ""), &dst); !errors.As(err, &queryErr) {
+		t.Errorf("Expected MalformedQueryError found %v", err)
+	}
+
+	var bodyErr *MalformedBodyError
+	if err := NewDecoder().DecodeRequest(newRequest("/", "application/x-www-form-urlencoded", "Name=%zz"), &dst); !errors.As(err, &bodyErr) {
+		t.Errorf("Expected MalformedBodyError found %v", err)
+	} else if bodyErr.ContentType != "application/x-www-form-urlencoded" {
+		t.Errorf("Unexpected content type in error. Expected \"application/x-www-form-urlencoded\" found %q", bodyErr.ContentType)
+	}
+
+	if err := NewDecoder().DecodeRequest(newRequest("/", "multipart/form-data", "Name=body"), &dst); !errors.As(err, &bodyErr) || !errors.Is(err, http.ErrMissingBoundary) {
+		t.Errorf("Expected MalformedBodyError for missing boundary found %v", err)
+	}
+
+	if err := NewDecoder().DecodeRequest(newRequest("/", "multipart/form-data; boundary=x", "Name=body"), &dst); !errors.As(err, &bodyErr) {
+		t.Errorf("Expected MalformedBodyError for multipart body found %v", err)
+	}
+
+	var typeErr *UnsupportedContentTypeError
+	if err := NewDecoder().DecodeRequest(newRequest("/", "application/json", `{"Name":"body"}`), &dst); !errors.As(err, &typeErr) {
+		t.Errorf("Expected UnsupportedContentTypeError found %v", err)
+	} else if typeErr.ContentType != "application/json" {
+		t.Errorf("Unexpected content type in error. Expected \"application/json\" found %q", typeErr.ContentType)
+	}
+
+	d := NewDecoder()
+	d.MaxBodySize(8)
+
+	var sizeErr *BodyTooLargeError
+	if err := d.DecodeRequest(newRequest("/", "application/x-www-form-urlencoded", "Name=body&Count=1"), &dst); !errors.As(err, &sizeErr) {
+		t.Errorf("Expected BodyTooLargeError found %v", err)
+	} else if sizeErr.Limit != 8 {
+		t.Errorf("Unexpected limit in error. Expected 8 found %d", sizeErr.Limit)
+	}
+
+	d.MaxBodySize(0)
+	if err := d.DecodeRequest(newRequest("/", "application/x-www-form-urlencoded", "Name=body&Count=1"), &dst); err != nil {
+		t.Errorf("DecodeRequest without a limit: %q", err)
+	}
+}
+
+func TestDecodeRequestSource(t *testing.T) {
+	type Meta struct {
+		Session string `form:"session,source=cookie"`
+	}
+
+	type sourceForm struct {
+		Meta
+		ID        int      `form:"id,source=path"`
+		RequestID string   `form:"X-Request-Id,source=header"`
+		Accept    []string `form:"accept,source=header"`
+		Tenant    string   `form:"tenant,source=path,default=main"`
+		Name      string
+	}
+
+	var dst sourceForm
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		d := NewDecoder()
+		d.DisallowUnknownFields(true)
+		if err := d.DecodeRequest(r, &dst); err != nil {
+			t.Errorf("DecodeRequest: %q", err)
+		}
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/items/42?id=1&X-Request-Id=query&session=query", strings.NewReader("Name=name&id=2"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header["x-request-id"] = []string{"abc"}
+	r.Header.Add("Accept", "text/html")
+	r.Header.Add("Accept", "application/json")
+	r.AddCookie(&http.Cookie{Name: "session", Value: "s"})
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+
+	want := sourceForm{
+		Meta:      Meta{Session: "s"},
+		ID:        42,
+		RequestID: "abc",
+		Accept:    []string{"text/html", "application/json"},
+		Tenant:    "main",
+		Name:      "name",
+	}
+
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("Unexpected value. Expected %+v found %+v", want, dst)
+	}
+}
+
+func TestDecodeRequestSourceMissing(t *testing.T) {
+	var dst struct {
+		ID      int    `form:"id,source=path,required"`
+		Session string `form:"session,source=cookie"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?id=1&session=query", nil)
+
+	var requiredErr *RequiredFieldError
+	if err := NewDecoder().DecodeRequest(r, &dst); !errors.As(err, &requiredErr) || requiredErr.Field != "id" {
+		t.Errorf("Expected RequiredFieldError for id found %v", err)
+	}
+
+	if dst.Session != "" {
+		t.Errorf("Unexpected value in Session. Expected it to be unset found %q", dst.Session)
+	}
+
+	var invalid struct {
+		ID int `form:"id,source=body"`
+	}
+
+	var sourceErr *SourceError
+	if err := NewDecoder().DecodeRequest(r, &invalid); !errors.As(err, &sourceErr) || sourceErr.Field != "ID" || sourceErr.Source != "body" {
+		t.Errorf("Expected SourceError found %v", err)
+	}
+}
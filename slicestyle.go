@@ -0,0 +1,209 @@
+package form
+
+import (
+	"fmt"
+	"mime/multipart"
+	"strconv"
+	"strings"
+)
+
+// SliceStyle is the format of the values of slice and array fields in a form. Downstream APIs
+// do not agree on a format, so the Encoder and Decoder can use any of them. The style of a
+// single field can be set with the slice option of its form tag, such as
+// `form:"ids,slice=comma"`. Slices of structs always use their indexes as sub keys.
+//
+// The sub keys of the brackets and indexed styles are joined and split with Recurse. If
+// Recurse is nil then they have the format a[] and a[0].
+type SliceStyle string
+
+const (
+	// SliceRepeat repeats the key for each value, such as a=1&a=2. It is the default.
+	SliceRepeat SliceStyle = "repeat"
+	// SliceBrackets adds an empty sub key to the key, such as a[]=1&a[]=2.
+	SliceBrackets SliceStyle = "brackets"
+	// SliceIndexed adds the index of each value as a sub key, such as a[0]=1&a[1]=2.
+	SliceIndexed SliceStyle = "indexed"
+	// SliceComma joins the values with commas into a single value, such as a=1,2. Values that
+	// contain commas cannot be decoded, so the Encoder returns a CommaValueError for them.
+	SliceComma SliceStyle = "comma"
+)
+
+// SliceStyle sets the style that the values of slice and array fields are decoded from.
+// Fields with the slice option use their own style. The default is SliceRepeat.
+func (d *Decoder) SliceStyle(s SliceStyle) {
+	d.sliceStyle = s
+}
+
+// sliceStyle returns the style of a field with the options opts, where def is the style of
+// the Encoder or Decoder.
+func sliceStyle(def SliceStyle, opts *fieldOptions) (SliceStyle, error) {
+	style := def
+	if opts.sliceStyle != "" {
+		style = opts.sliceStyle
+	}
+
+	switch style {
+	case "", SliceRepeat:
+		return SliceRepeat, nil
+
+	case SliceBrackets, SliceIndexed, SliceComma:
+		return style, nil
+	}
+
+	return "", &SliceStyleError{Style: style}
+}
+
+// isSliceRepeat reports whether s is the default style, which generated code uses.
+func isSliceRepeat(s SliceStyle) bool {
+	return s == "" || s == SliceRepeat
+}
+
+// splitComma splits the values of a field with the comma style into its elements. An empty
+// value has no elements.
+func splitComma(vals []string) []string {
+	split := make([]string, 0, len(vals))
+	for _, v := range vals {
+		if v != "" {
+			split = append(split, strings.Split(v, ",")...)
+		}
+	}
+
+	return split
+}
+
+// bracketLayer moves the values of the empty sub key of a field with the brackets style, such
+// as a[], into the values of the field.
+func bracketLayer(layer *formLayer) *formLayer {
+	sub := layer.subVals[""]
+	if sub == nil || sub.val == nil || sub.subVals != nil {
+		return layer
+	}
+
+	val := sub.val
+	if layer.val != nil {
+		val = &formVal{
+			key:   layer.val.key,
+			vals:  append(append([]string(nil), layer.val.vals...), sub.val.vals...),
+			files: append(append([]*multipart.FileHeader(nil), layer.val.files...), sub.val.files...),
+		}
+	}
+
+	subVals := make(map[string]*formLayer, len(layer.subVals)-1)
+	for k, v := range layer.subVals {
+		if k != "" {
+			subVals[k] = v
+		}
+	}
+
+	if len(subVals) == 0 {
+		subVals = nil
+	}
+
+	return &formLayer{val: val, subVals: subVals}
+}
+
+// groupSliceKeys moves keys such as a[] and a[0] under the layer of the slice field a, as
+// Recurse would, for fields with the brackets or indexed style. It is used when Recurse is nil.
+// Keys that match a field are left as they are.
+func (d *Decoder) groupSliceKeys(vals map[string]*formLayer, plan *structPlan) map[string]*formLayer {
+	var grouped map[string]*formLayer
+	for k, layer := range vals {
+		if _, ok := plan.entries[k]; ok {
+			continue
+		}
+
+		name, sub, ok := splitSliceKey(k)
+		if !ok {
+			continue
+		}
+
+		entry, ok := plan.entries[name]
+		if !ok {
+			continue
+		}
+
+		if !isList(entry.typ) {
+			continue
+		}
+
+		switch style, _ := sliceStyle(d.sliceStyle, entry.opts); {
+		case style == SliceBrackets && sub == "":
+		case style == SliceIndexed && sub != "":
+		default:
+			continue
+		}
+
+		if grouped == nil {
+			grouped = make(map[string]*formLayer, len(vals))
+			for k, v := range vals {
+				grouped[k] = v
+			}
+		}
+
+		delete(grouped, k)
+		parent := grouped[name]
+		if parent == nil || parent == vals[name] {
+			// Copy the layer of the field so that the layers of vals are not changed.
+			parent = &formLayer{}
+			if prev := vals[name]; prev != nil {
+				parent.val = prev.val
+			}
+
+			grouped[name] = parent
+		}
+
+		if parent.subVals == nil {
+			parent.subVals = make(map[string]*formLayer)
+		}
+
+		parent.subVals[sub] = layer
+	}
+
+	if grouped == nil {
+		return vals
+	}
+
+	return grouped
+}
+
+// splitSliceKey splits a key of the form a[] or a[0] into a and the sub key.
+func splitSliceKey(key string) (name, sub string, ok bool) {
+	if !strings.HasSuffix(key, "]") {
+		return "", "", false
+	}
+
+	i := strings.LastIndexByte(key, '[')
+	if i <= 0 {
+		return "", "", false
+	}
+
+	name, sub = key[:i], key[i+1:len(key)-1]
+	if sub != "" {
+		if n, err := strconv.Atoi(sub); err != nil || n < 0 {
+			return "", "", false
+		}
+	}
+
+	return name, sub, true
+}
+
+// SliceStyleError is returned when the slice style of the Encoder, Decoder or a field is not
+// one of the SliceStyle constants.
+type SliceStyleError struct {
+	Style SliceStyle
+}
+
+func (e *SliceStyleError) Error() string {
+	return buildErrorMessage("Parse", fmt.Sprintf("unknown slice style %q", e.Style))
+}
+
+// CommaValueError is returned by the Encoder when a value of a field with the comma style
+// contains a comma, as it would be split into two values when decoded.
+type CommaValueError struct {
+	Key   string
+	Value string
+}
+
+func (e *CommaValueError) Error() string {
+	return buildErrorMessage("Encode", fmt.Sprintf("value %q of %s contains a comma", e.Value, e.Key))
+}
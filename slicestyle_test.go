@@ -0,0 +1,277 @@
+package form
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeSliceStyle(t *testing.T) {
+	type Filter struct {
+		IDs []int `form:"ids"`
+	}
+
+	type styleForm struct {
+		Tags   []string  `form:"tags"`
+		RGB    [3]uint8  `form:"rgb"`
+		Empty  []string  `form:"empty"`
+		Fields []string  `form:"fields,slice=comma"`
+		Filter Filter    `form:"filter"`
+		Ptrs   []*string `form:"ptrs"`
+	}
+
+	a := "a"
+	src := styleForm{
+		Tags:   []string{"x", "y"},
+		RGB:    [3]uint8{255, 128, 0},
+		Fields: []string{"name", "email"},
+		Filter: Filter{IDs: []int{1, 2}},
+		Ptrs:   []*string{nil, &a},
+	}
+
+	tests := []struct {
+		style   SliceStyle
+		recurse EncodeSubKeyFunc
+		want    url.Values
+	}{
+		{
+			style:   SliceRepeat,
+			recurse: ListMapEncodeFunc,
+			want: url.Values{
+				"tags":        {"x", "y"},
+				"rgb":         {"255", "128", "0"},
+				"empty":       {},
+				"fields":      {"name,email"},
+				"filter[ids]": {"1", "2"},
+				"ptrs":        {"a"},
+			},
+		},
+		{
+			style:   SliceBrackets,
+			recurse: ListMapEncodeFunc,
+			want: url.Values{
+				"tags[]":        {"x", "y"},
+				"rgb[]":         {"255", "128", "0"},
+				"empty[]":       {},
+				"fields":        {"name,email"},
+				"filter[ids][]": {"1", "2"},
+				"ptrs[]":        {"a"},
+			},
+		},
+		{
+			style:   SliceIndexed,
+			recurse: NestedMapEncodeFunc,
+			want: url.Values{
+				"tags[0]":        {"x"},
+				"tags[1]":        {"y"},
+				"rgb[0]":         {"255"},
+				"rgb[1]":         {"128"},
+				"rgb[2]":         {"0"},
+				"fields":         {"name,email"},
+				"filter[ids[0]]": {"1"},
+				"filter[ids[1]]": {"2"},
+				"ptrs[0]":        {"a"},
+			},
+		},
+		{
+			style:   SliceComma,
+			recurse: ListEncodeFunc,
+			want: url.Values{
+				"tags":       {"x,y"},
+				"rgb":        {"255,128,0"},
+				"empty":      {},
+				"fields":     {"name,email"},
+				"filter.ids": {"1,2"},
+				"ptrs":       {"a"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		e := &Encoder{Recurse: test.recurse, SliceStyle: test.style}
+		vals, err := e.Encode(src)
+		if err != nil {
+			t.Fatalf("Encode %s: %q", test.style, err)
+		}
+
+		if !reflect.DeepEqual(vals, test.want) {
+			t.Errorf("Unexpected values for %s.\nExpected %v\nfound    %v", test.style, test.want, vals)
+		}
+	}
+
+	// The keys of the brackets and indexed styles do not need Recurse.
+	vals, err := (&Encoder{SliceStyle: SliceIndexed}).Encode(struct {
+		Tags []string `form:"tags"`
+		IDs  []int    `form:"ids,slice=brackets"`
+	}{Tags: []string{"x"}, IDs: []int{1}})
+	if err != nil {
+		t.Fatalf("Encode: %q", err)
+	}
+
+	want := url.Values{
+		"tags[0]": {"x"},
+		"ids[]":   {"1"},
+	}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Unexpected values without Recurse. Expected %v found %v", want, vals)
+	}
+}
+
+func TestDecodeSliceStyle(t *testing.T) {
+	type styleForm struct {
+		Tags   []string `form:"tags"`
+		RGB    [3]uint8 `form:"rgb"`
+		Fields []string `form:"fields,slice=comma"`
+		Name   string   `form:"name"`
+	}
+
+	want := styleForm{
+		Tags:   []string{"x", "y"},
+		RGB:    [3]uint8{255, 128, 0},
+		Fields: []string{"name", "email"},
+		Name:   "n",
+	}
+
+	tests := []struct {
+		style   SliceStyle
+		recurse DecodeSubKeyFunc
+		query   string
+	}{
+		{SliceRepeat, nil, "tags=x&tags=y&rgb=255&rgb=128&rgb=0&fields=name,email&name=n"},
+		{SliceBrackets, nil, "tags[]=x&tags[]=y&rgb[]=255&rgb[]=128&rgb[]=0&fields=name,email&name=n"},
+		{SliceBrackets, ListMapDecodeFunc, "tags[]=x&tags[]=y&rgb[]=255&rgb[]=128&rgb[]=0&fields=name,email&name=n"},
+		{SliceIndexed, nil, "tags[1]=y&tags[0]=x&rgb[0]=255&rgb[1]=128&rgb[2]=0&fields=name,email&name=n"},
+		{SliceIndexed, ListDecodeFunc, "tags.1=y&tags.0=x&rgb.0=255&rgb.1=128&rgb.2=0&fields=name,email&name=n"},
+		{SliceComma, nil, "tags=x,y&rgb=255,128&rgb=0&fields=name,email&name=n"},
+	}
+
+	for _, test := range tests {
+		vals, err := url.ParseQuery(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		d := NewDecoder()
+		d.Recurse(test.recurse)
+		d.SliceStyle(test.style)
+		d.DisallowUnknownFields(true)
+
+		var dst styleForm
+		if err := d.Decode(vals, &dst); err != nil {
+			t.Errorf("Decode %s %q: %q", test.style, test.query, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(dst, want) {
+			t.Errorf("Unexpected value for %s %q. Expected %+v found %+v", test.style, test.query, want, dst)
+		}
+	}
+
+	// Keys in another style are unexpected.
+	d := NewDecoder()
+	d.DisallowUnknownFields(true)
+
+	var dst styleForm
+	var unexpectedErr *UnexpectedFieldError
+	if err := d.Decode(url.Values{"tags[]": {"x"}}, &dst); !errors.As(err, &unexpectedErr) {
+		t.Errorf("Decode not an unexpected field error: %q", err)
+	}
+}
+
+func TestSliceStyleRoundTrip(t *testing.T) {
+	type Item struct {
+		SKU  string   `form:"sku"`
+		Tags []string `form:"tags"`
+	}
+
+	type roundTripForm struct {
+		Tags  []string       `form:"tags"`
+		IDs   []int          `form:"ids,slice=comma"`
+		Pair  [2]string      `form:"pair"`
+		Item  Item           `form:"item"`
+		Items []Item         `form:"items"`
+		Named map[string]int `form:"named"`
+	}
+
+	src := roundTripForm{
+		Tags:  []string{"x", "y"},
+		IDs:   []int{1, 2},
+		Pair:  [2]string{"a", "b"},
+		Item:  Item{SKU: "A", Tags: []string{"t"}},
+		Items: []Item{{SKU: "B", Tags: []string{"u", "v"}}},
+		Named: map[string]int{"n": 1},
+	}
+
+	recurse := []struct {
+		enc EncodeSubKeyFunc
+		dec DecodeSubKeyFunc
+	}{
+		{NestedMapEncodeFunc, NestedMapDecodeFunc},
+		{ListMapEncodeFunc, ListMapDecodeFunc},
+		{ListEncodeFunc, ListDecodeFunc},
+	}
+
+	for _, style := range []SliceStyle{SliceRepeat, SliceBrackets, SliceIndexed, SliceComma} {
+		for i, r := range recurse {
+			encoded, err := (&Encoder{Recurse: r.enc, SliceStyle: style}).EncodeString(src)
+			if err != nil {
+				t.Fatalf("Encode %s %d: %q", style, i, err)
+			}
+
+			vals, err := url.ParseQuery(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			d := NewDecoder()
+			d.Recurse(r.dec)
+			d.SliceStyle(style)
+			d.DisallowUnknownFields(true)
+
+			var dst roundTripForm
+			if err := d.Decode(vals, &dst); err != nil {
+				t.Errorf("Decode %s %d %q: %q", style, i, encoded, err)
+				continue
+			}
+
+			if !reflect.DeepEqual(dst, src) {
+				t.Errorf("Unexpected round trip for %s %d %q. Expected %+v found %+v", style, i, encoded, src, dst)
+			}
+		}
+	}
+}
+
+func TestSliceStyleErrors(t *testing.T) {
+	var styleErr *SliceStyleError
+	if _, err := (&Encoder{SliceStyle: "pipes"}).Encode(struct{ Tags []string }{}); !errors.As(err, &styleErr) {
+		t.Errorf("Encode not a slice style error: %q", err)
+	}
+
+	var dst struct {
+		Tags []string `form:"tags,slice=pipes"`
+		Name string
+	}
+
+	if err := NewDecoder().Decode(url.Values{"tags": {"x"}, "Name": {"n"}}, &dst); !errors.As(err, &styleErr) {
+		t.Errorf("Decode not a slice style error: %q", err)
+	} else if styleErr.Style != "pipes" {
+		t.Errorf("Unexpected style in styleErr. Expected \"pipes\" found %q", styleErr.Style)
+	}
+
+	if status := ErrorStatus(styleErr); status != 500 {
+		t.Errorf("Unexpected status for slice style error. Expected 500 found %d", status)
+	}
+
+	var commaErr *CommaValueError
+	_, err := (&Encoder{SliceStyle: SliceComma}).Encode(struct {
+		Tags []string `form:"tags"`
+	}{Tags: []string{"a", "b,c"}})
+	if !errors.As(err, &commaErr) {
+		t.Fatalf("Encode not a comma value error: %q", err)
+	}
+
+	if commaErr.Key != "tags" || commaErr.Value != "b,c" {
+		t.Errorf("Unexpected comma value error. Expected tags \"b,c\" found %s %q", commaErr.Key, commaErr.Value)
+	}
+}
@@ -0,0 +1,81 @@
+package form
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// suggestField returns the candidate closest to name, ignoring case, or an empty string if
+// none of them are close enough to be a likely typo. The name comes from the form, so
+// candidates whose length is too different to be close are skipped before comparing them.
+func suggestField(name string, candidates []string) string {
+	nameRunes := []rune(strings.ToLower(name))
+
+	best := ""
+	bestDist := 0
+	for _, candidate := range candidates {
+		// Allow roughly one mistake for every three characters, up to a maximum of three.
+		maxDist := len(candidate) / 3
+		if maxDist < 1 {
+			maxDist = 1
+		} else if maxDist > 3 {
+			maxDist = 3
+		}
+
+		if diff := len(nameRunes) - utf8.RuneCountInString(candidate); diff > maxDist || -diff > maxDist {
+			continue
+		}
+
+		dist := editDistance(nameRunes, []rune(strings.ToLower(candidate)))
+		if dist > maxDist {
+			continue
+		}
+
+		if best == "" || dist < bestDist || (dist == bestDist && candidate < best) {
+			best = candidate
+			bestDist = dist
+		}
+	}
+
+	return best
+}
+
+// editDistance returns the number of single character insertions, deletions, substitutions
+// and transpositions of adjacent characters needed to turn a into b. Only the last three rows
+// of the distance matrix are kept.
+func editDistance(a, b []rune) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			d := prev[j-1] + cost
+			if prev[j]+1 < d {
+				d = prev[j] + 1
+			}
+			if curr[j-1]+1 < d {
+				d = curr[j-1] + 1
+			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && prev2[j-2]+1 < d {
+				d = prev2[j-2] + 1
+			}
+
+			curr[j] = d
+		}
+
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return prev[len(b)]
+}
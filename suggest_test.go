@@ -0,0 +1,62 @@
+package form
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSuggestField(t *testing.T) {
+	candidates := []string{"Email", "Name", "PostCode", "ID"}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"email", "Email"},
+		{"emial", "Email"},
+		{"nmae", "Name"},
+		{"postcde", "PostCode"},
+		{"postal_code", ""},
+		{"id", "ID"},
+		{"xy", ""},
+		{"csrf_token", ""},
+	}
+
+	for _, tt := range tests {
+		if got := suggestField(tt.name, candidates); got != tt.want {
+			t.Errorf("suggestField(%q) = %q - want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// Long keys are skipped by their length without comparing them to every candidate.
+	long := strings.Repeat("e", 200<<10)
+	allocs := testing.AllocsPerRun(10, func() {
+		if got := suggestField(long, candidates); got != "" {
+			t.Errorf("suggestField(long) = %q - want \"\"", got)
+		}
+	})
+	if allocs > 2 {
+		t.Errorf("Unexpected allocations in suggestField(long). Expected at most 2 found %v", allocs)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"ab", "ba", 1},
+		{"same", "same", 0},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d - want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
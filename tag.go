@@ -0,0 +1,141 @@
+package form
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// fieldOptions holds the options for a field that are read from its struct tags. They are
+// shared by the Decoder and the Encoder.
+//
+// The form tag has the format "name,option,option=value". The name defaults to the name of
+// the field if it is empty and the field is skipped if the tag is "-". The options are:
+//
+//	omitempty   the Encoder skips the field if it is empty, or if IsZero() reports true
+//	required    the Decoder returns a RequiredFieldError if the field is not in the form
+//	default=val the Decoder decodes val into the field if it is not in the form
+//	source=src  DecodeRequest reads the field from the path, header or cookie of the request
+//	slice=style the SliceStyle of the field, such as comma
+//
+// Option values cannot contain commas. Other options return a TagOptionError.
+type fieldOptions struct {
+	// name is the form name of the field.
+	name string
+	// skip is set for fields tagged with "-".
+	skip      bool
+	omitEmpty bool
+	required  bool
+	// hasDefault is set if defaultVal should be used when the field is missing. This allows
+	// an empty default value.
+	hasDefault bool
+	defaultVal string
+	// source is the part of a request that DecodeRequest reads the field from. It is empty
+	// for the form values.
+	source string
+	// sliceStyle overrides the slice style of the Encoder and Decoder if it is set.
+	sliceStyle SliceStyle
+	// layout is the time layout used for time.Time fields.
+	layout string
+	// rules are read from the validate tag.
+	rules []fieldRule
+	// fileLimits are the limits for file fields read from the file tag.
+	fileLimits fileLimits
+	// err is set if the form tag has an unknown option or the file tag cannot be read. It is
+	// returned when the field is decoded or encoded rather than by readFieldOptions so that
+	// the fields can be cached.
+	err error
+}
+
+func readFieldOptions(field reflect.StructField) *fieldOptions {
+	opts := &fieldOptions{
+		name:   field.Name,
+		layout: field.Tag.Get("layout"),
+		rules:  readRules(field.Tag.Get("validate")),
+	}
+
+	var err error
+	if opts.fileLimits, err = readFileLimits(readRules(field.Tag.Get("file"))); err != nil {
+		opts.err = fmt.Errorf("file tag of field %s: %w", field.Name, err)
+	}
+
+	tag := field.Tag.Get("form")
+	if tag == "-" {
+		opts.skip = true
+		return opts
+	}
+
+	parts := strings.Split(tag, ",")
+	if parts[0] != "" {
+		opts.name = parts[0]
+	}
+
+	for _, part := range parts[1:] {
+		option, value, _ := strings.Cut(part, "=")
+		switch option {
+		case "omitempty":
+			opts.omitEmpty = true
+
+		case "required":
+			opts.required = true
+
+		case "default":
+			opts.hasDefault = true
+			opts.defaultVal = value
+
+		case "source":
+			opts.source = value
+
+		case "slice":
+			opts.sliceStyle = SliceStyle(value)
+
+		default:
+			if opts.err == nil {
+				opts.err = &TagOptionError{Field: field.Name, Option: option}
+			}
+		}
+	}
+
+	return opts
+}
+
+// TagOptionError is returned when the form tag of a field has an option that is not one of
+// the options of fieldOptions, such as a misspelt `form:"name,requird"`.
+type TagOptionError struct {
+	// Field is the name of the field in the struct.
+	Field  string
+	Option string
+}
+
+func (e *TagOptionError) Error() string {
+	return buildErrorMessage("Parse", fmt.Sprintf("unknown option %q for field %q", e.Option, e.Field))
+}
+
+// isEmptyValue reports whether v is empty for the omitempty option. This is the same as the
+// encoding/json package. The Encoder checks for IsZero() bool methods first.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+
+	case reflect.Bool:
+		return !v.Bool()
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+
+	case reflect.Complex64, reflect.Complex128:
+		return v.Complex() == 0
+
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+package form
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Layouts for the values sent by the HTML date and time inputs. Any of these, or any other
+// layout accepted by time.Parse, can be used in the layout tag of a time.Time field:
+//
+//	Birthday time.Time `layout:"2006-01-02"`
+//
+// Fields without a layout tag use time.RFC3339.
+const (
+	// LayoutDate is the layout of <input type="date">.
+	LayoutDate = "2006-01-02"
+	// LayoutDateTimeLocal is the layout of <input type="datetime-local">. Values with seconds
+	// are also accepted when decoding.
+	LayoutDateTimeLocal = "2006-01-02T15:04"
+	// LayoutTime is the layout of <input type="time">. Values with seconds are also accepted
+	// when decoding.
+	LayoutTime = "15:04"
+	// LayoutMonth is the layout of <input type="month">.
+	LayoutMonth = "2006-01"
+	// LayoutWeek is the layout of <input type="week">. This is an ISO 8601 week which is not
+	// supported by time.Parse so it is handled separately. Decoded values are the Monday at
+	// the start of the week.
+	LayoutWeek = "2006-W01"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// timeLayout returns the layout to use for a field, defaulting to time.RFC3339.
+func timeLayout(layout string) string {
+	if layout == "" {
+		return time.RFC3339
+	}
+
+	return layout
+}
+
+// parseTime parses a time using the layout. Values without a time zone are interpreted in loc.
+func parseTime(vals []string, layout string, loc *time.Location) (time.Time, error) {
+	s, err := parseString(vals)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	layout = timeLayout(layout)
+	if layout == LayoutWeek {
+		return parseWeek(s, loc)
+	}
+
+	t, err := time.ParseInLocation(layout, s, loc)
+	if err != nil && (layout == LayoutDateTimeLocal || layout == LayoutTime) {
+		// The inputs include seconds when their step attribute is less than a minute.
+		t, err = time.ParseInLocation(layout+":05", s, loc)
+	}
+
+	if err != nil {
+		return time.Time{}, &UnexpectedValueError{s}
+	}
+
+	return t, nil
+}
+
+// formatTime formats a time using the layout.
+func formatTime(t time.Time, layout string) string {
+	layout = timeLayout(layout)
+	if layout == LayoutWeek {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	}
+
+	return t.Format(layout)
+}
+
+// parseWeek parses an ISO 8601 week such as 2006-W01 into the Monday at the start of the week.
+func parseWeek(s string, loc *time.Location) (time.Time, error) {
+	if len(s) != len(LayoutWeek) || s[4:6] != "-W" {
+		return time.Time{}, &UnexpectedValueError{s}
+	}
+
+	year, err := strconv.Atoi(s[:4])
+	if err != nil {
+		return time.Time{}, &UnexpectedValueError{s}
+	}
+
+	week, err := strconv.Atoi(s[6:])
+	if err != nil {
+		return time.Time{}, &UnexpectedValueError{s}
+	}
+
+	// Week 1 is the week containing the 4th of January.
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	offset := (int(jan4.Weekday()) + 6) % 7
+	t := jan4.AddDate(0, 0, (week-1)*7-offset)
+
+	// Rejects week 0 and week 53 in years that only have 52 weeks.
+	if gotYear, gotWeek := t.ISOWeek(); gotYear != year || gotWeek != week {
+		return time.Time{}, &UnexpectedValueError{s}
+	}
+
+	return t, nil
+}
@@ -0,0 +1,120 @@
+package form
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	testStruct := struct {
+		Default  time.Time
+		Date     time.Time   `layout:"2006-01-02"`
+		Local    time.Time   `layout:"2006-01-02T15:04"`
+		Clock    time.Time   `layout:"15:04"`
+		Month    time.Time   `layout:"2006-01"`
+		Week     time.Time   `layout:"2006-W01"`
+		Custom   *time.Time  `layout:"02/01/2006"`
+		Dates    []time.Time `layout:"2006-01-02"`
+		Duration time.Duration
+	}{}
+
+	vals := url.Values{
+		"Default":  []string{"2021-03-04T05:06:07+02:00"},
+		"Date":     []string{"2021-03-04"},
+		"Local":    []string{"2021-03-04T05:06:07"},
+		"Clock":    []string{"05:06"},
+		"Month":    []string{"2021-03"},
+		"Week":     []string{"2021-W01"},
+		"Custom":   []string{"04/03/2021"},
+		"Dates":    []string{"2021-03-04", "2021-03-05"},
+		"Duration": []string{"1h30m"},
+	}
+
+	loc := time.FixedZone("test", -5*60*60)
+	d := NewDecoder()
+	d.Location(loc)
+	if err := d.Decode(vals, &testStruct); err != nil {
+		t.Fatalf("Decode: %q", err)
+	}
+
+	tests := []struct {
+		name string
+		got  time.Time
+		want time.Time
+	}{
+		{"Default", testStruct.Default, time.Date(2021, 3, 4, 5, 6, 7, 0, time.FixedZone("", 2*60*60))},
+		{"Date", testStruct.Date, time.Date(2021, 3, 4, 0, 0, 0, 0, loc)},
+		{"Local", testStruct.Local, time.Date(2021, 3, 4, 5, 6, 7, 0, loc)},
+		{"Clock", testStruct.Clock, time.Date(0, 1, 1, 5, 6, 0, 0, loc)},
+		{"Month", testStruct.Month, time.Date(2021, 3, 1, 0, 0, 0, 0, loc)},
+		{"Week", testStruct.Week, time.Date(2021, 1, 4, 0, 0, 0, 0, loc)},
+		{"Dates[1]", testStruct.Dates[1], time.Date(2021, 3, 5, 0, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		if !tt.got.Equal(tt.want) || tt.got.Location().String() != tt.want.Location().String() {
+			t.Errorf("Unexpected value in %s. Expected %v found %v", tt.name, tt.want, tt.got)
+		}
+	}
+
+	if want := time.Date(2021, 3, 4, 0, 0, 0, 0, loc); testStruct.Custom == nil || !testStruct.Custom.Equal(want) {
+		t.Errorf("Unexpected value in Custom. Expected %v found %v", want, testStruct.Custom)
+	}
+	if want := 90 * time.Minute; testStruct.Duration != want {
+		t.Errorf("Unexpected value in Duration. Expected %v found %v", want, testStruct.Duration)
+	}
+
+	for _, week := range []string{"2021-W00", "2021-W53", "2021-X01", "2021-W1"} {
+		vals = url.Values{
+			"Week": []string{week},
+		}
+		var unexpectedValueError *UnexpectedValueError
+		if err := d.Decode(vals, &testStruct); !errors.As(err, &unexpectedValueError) {
+			t.Errorf("Decode(%q) not an unexpected value error: %q", week, err)
+		}
+	}
+
+	// 2020 has 53 weeks. The first one starts in 2019.
+	vals = url.Values{
+		"Week": []string{"2020-W53"},
+	}
+	if err := d.Decode(vals, &testStruct); err != nil {
+		t.Fatalf("Decode: %q", err)
+	}
+	if want := time.Date(2020, 12, 28, 0, 0, 0, 0, loc); !testStruct.Week.Equal(want) {
+		t.Errorf("Unexpected value in Week. Expected %v found %v", want, testStruct.Week)
+	}
+}
+
+func TestEncodeTime(t *testing.T) {
+	tm := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	testStruct := struct {
+		Default  time.Time
+		Date     time.Time `layout:"2006-01-02"`
+		Week     time.Time `layout:"2006-W01"`
+		Duration time.Duration
+	}{
+		Default:  tm,
+		Date:     tm,
+		Week:     tm,
+		Duration: 90 * time.Minute,
+	}
+
+	e := &Encoder{Location: time.FixedZone("test", -6*60*60)}
+	vals, err := e.Encode(testStruct)
+	if err != nil {
+		t.Fatalf("Encode: %q", err)
+	}
+
+	want := url.Values{
+		"Default":  []string{"2021-03-03T23:06:07-06:00"},
+		"Date":     []string{"2021-03-03"},
+		"Week":     []string{"2021-W09"},
+		"Duration": []string{"1h30m0s"},
+	}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Unexpected values. Expected %v found %v", want, vals)
+	}
+}
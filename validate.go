@@ -0,0 +1,343 @@
+package form
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ValidationRule checks a decoded field value. param is the text after the "=" in the rule,
+// or empty if there is none. The returned error is wrapped in a ValidationError.
+type ValidationRule func(v reflect.Value, param string) error
+
+// The built in rules for the validate tag. Rules are separated by commas:
+//
+//	Age   int      `validate:"min=18,max=130"`
+//	Code  string   `validate:"len=6,pattern=^[A-Z0-9]+$"`
+//	Tags  []string `validate:"maxlen=5,oneof=red green blue"`
+//	Email string   `validate:"email"`
+//
+// min and max compare numbers. len, minlen and maxlen check the number of characters in a
+// string or the number of elements in a slice, array or map. The other rules check strings,
+// and the elements of slices and arrays one at a time. oneof takes a space separated list of
+// allowed values and works on any type that is formatted to one of them. The pattern rule
+// takes the rest of the tag so that the regular expression can contain commas.
+var builtinRules = map[string]ValidationRule{
+	"min":     validateMin,
+	"max":     validateMax,
+	"len":     validateLen,
+	"minlen":  validateMinLen,
+	"maxlen":  validateMaxLen,
+	"pattern": validatePattern,
+	"oneof":   validateOneOf,
+	"email":   validateEmail,
+	"url":     validateURL,
+}
+
+// fieldRule is a rule read from the validate tag of a field.
+type fieldRule struct {
+	name  string
+	param string
+}
+
+// readRules reads the rules from a validate tag.
+func readRules(tag string) []fieldRule {
+	if tag == "" {
+		return nil
+	}
+
+	var rules []fieldRule
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "pattern=") {
+			part, tag = tag, ""
+		} else {
+			part, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, param, _ := strings.Cut(part, "=")
+		rules = append(rules, fieldRule{name: name, param: param})
+	}
+
+	return rules
+}
+
+// RegisterRule adds a rule that can be used in the validate tag of fields decoded by d. It
+// replaces any built in rule with the same name.
+func (d *Decoder) RegisterRule(name string, rule ValidationRule) {
+	if d.rules == nil {
+		d.rules = make(map[string]ValidationRule)
+	}
+
+	d.rules[name] = rule
+}
+
+// validate runs the rules of a field against its decoded value.
+func (d *Decoder) validate(v reflect.Value, rules []fieldRule) error {
+	for _, r := range rules {
+		rule, ok := d.rules[r.name]
+		if !ok {
+			rule, ok = builtinRules[r.name]
+		}
+
+		if !ok {
+			return &ValidationError{Rule: r.name, Param: r.param, Err: errUnknownRule}
+		}
+
+		if err := rule(v, r.param); err != nil {
+			return &ValidationError{Rule: r.name, Param: r.param, Err: err}
+		}
+	}
+
+	return nil
+}
+
+var errUnknownRule = errors.New("unknown rule")
+
+// errInvalidRule is wrapped by the errors of built in rules with a param that cannot be parsed
+// or that are used on a field of the wrong type. These are mistakes in the validate tag rather
+// than the form.
+var errInvalidRule = errors.New("invalid rule")
+
+// ValidationError is the Err of a FieldParseError when a decoded value fails a rule from
+// its validate tag.
+type ValidationError struct {
+	Rule  string
+	Param string
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Rule, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func validateMin(v reflect.Value, param string) error {
+	return eachElem(v, func(v reflect.Value) error {
+		if n, ok := compareNumber(v, param); !ok {
+			return fmt.Errorf("%w: cannot compare %s to %q", errInvalidRule, v.Type(), param)
+		} else if n < 0 {
+			return fmt.Errorf("must be at least %s", param)
+		}
+
+		return nil
+	})
+}
+
+func validateMax(v reflect.Value, param string) error {
+	return eachElem(v, func(v reflect.Value) error {
+		if n, ok := compareNumber(v, param); !ok {
+			return fmt.Errorf("%w: cannot compare %s to %q", errInvalidRule, v.Type(), param)
+		} else if n > 0 {
+			return fmt.Errorf("must be at most %s", param)
+		}
+
+		return nil
+	})
+}
+
+func validateLen(v reflect.Value, param string) error {
+	n, want, err := lengths(v, param)
+	if err != nil {
+		return err
+	}
+
+	if n != want {
+		return fmt.Errorf("length must be %d", want)
+	}
+
+	return nil
+}
+
+func validateMinLen(v reflect.Value, param string) error {
+	n, want, err := lengths(v, param)
+	if err != nil {
+		return err
+	}
+
+	if n < want {
+		return fmt.Errorf("length must be at least %d", want)
+	}
+
+	return nil
+}
+
+func validateMaxLen(v reflect.Value, param string) error {
+	n, want, err := lengths(v, param)
+	if err != nil {
+		return err
+	}
+
+	if n > want {
+		return fmt.Errorf("length must be at most %d", want)
+	}
+
+	return nil
+}
+
+var patterns sync.Map
+
+func validatePattern(v reflect.Value, param string) error {
+	re, ok := patterns.Load(param)
+	if !ok {
+		compiled, err := regexp.Compile(param)
+		if err != nil {
+			return fmt.Errorf("%w: %s", errInvalidRule, err)
+		}
+
+		re, _ = patterns.LoadOrStore(param, compiled)
+	}
+
+	return eachString(v, func(s string) error {
+		if !re.(*regexp.Regexp).MatchString(s) {
+			return fmt.Errorf("must match %s", param)
+		}
+
+		return nil
+	})
+}
+
+func validateOneOf(v reflect.Value, param string) error {
+	allowed := strings.Fields(param)
+	return eachElem(v, func(v reflect.Value) error {
+		s := fmt.Sprint(v.Interface())
+		for _, a := range allowed {
+			if s == a {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("must be one of %s", strings.Join(allowed, ", "))
+	})
+}
+
+func validateEmail(v reflect.Value, _ string) error {
+	return eachString(v, func(s string) error {
+		addr, err := mail.ParseAddress(s)
+		if err != nil || addr.Address != s {
+			return errors.New("must be an email address")
+		}
+
+		return nil
+	})
+}
+
+func validateURL(v reflect.Value, _ string) error {
+	return eachString(v, func(s string) error {
+		u, err := url.Parse(s)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.New("must be an absolute URL")
+		}
+
+		return nil
+	})
+}
+
+// eachElem calls f with v, or each element of v if it is a slice or array. Nil pointers are
+// skipped.
+func eachElem(v reflect.Value, f func(v reflect.Value) error) error {
+	v = reflect.Indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return f(v)
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		if err := eachElem(v.Index(i), f); err != nil {
+			return fmt.Errorf("element %d %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// eachString calls f with each string in v.
+func eachString(v reflect.Value, f func(s string) error) error {
+	return eachElem(v, func(v reflect.Value) error {
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("%w: cannot validate %s as a string", errInvalidRule, v.Type())
+		}
+
+		return f(v.String())
+	})
+}
+
+// compareNumber compares a number to param, returning -1, 0 or +1. ok is false if v is not a
+// number or param cannot be parsed as the same type of number.
+func compareNumber(v reflect.Value, param string) (n int, ok bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		p, err := strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+
+		return compare(v.Int(), p), true
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		p, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+
+		return compare(v.Uint(), p), true
+
+	case reflect.Float32, reflect.Float64:
+		p, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return 0, false
+		}
+
+		return compare(v.Float(), p), true
+	}
+
+	return 0, false
+}
+
+func compare[T int64 | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// lengths returns the length of v and the length in param. Strings are measured in
+// characters.
+func lengths(v reflect.Value, param string) (n, want int, err error) {
+	want, err = strconv.Atoi(param)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: invalid length %q", errInvalidRule, param)
+	}
+
+	v = reflect.Indirect(v)
+	if !v.IsValid() {
+		return 0, want, nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(v.String()), want, nil
+
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len(), want, nil
+	}
+
+	return 0, 0, fmt.Errorf("%w: cannot measure the length of %s", errInvalidRule, v.Type())
+}